@@ -23,6 +23,19 @@ func main() {
 	app.Version = "0.0.2"
 	app.Usage = "Toolbelt to work with Statecraft API"
 
+	//
+	// Profiles
+	//
+
+	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:   "profile, p",
+			Usage:  "Profile from ~/.borg.yaml or ./.borg.yaml",
+			EnvVar: "BORG_PROFILE",
+		},
+	}
+	app.Before = commands.LoadProfile
+
 	//
 	// Commands
 	//
@@ -38,6 +51,11 @@ func main() {
 	app.Commands = append(app.Commands, commands.CreateCursorCommands()...)
 	app.Commands = append(app.Commands, commands.CreateNormalizeCommands()...)
 	app.Commands = append(app.Commands, commands.CreateZoningCommands()...)
+	app.Commands = append(app.Commands, commands.CreateFrontageCommands()...)
+	app.Commands = append(app.Commands, commands.CreateNeighborsCommands()...)
+	app.Commands = append(app.Commands, commands.CreateValidateCommands()...)
+	app.Commands = append(app.Commands, commands.CreateSchemaCommands()...)
+	app.Commands = append(app.Commands, commands.CreateStatsCommands()...)
 	app.Commands = append(app.Commands, commands.CreateExportCommands()...)
 	app.Commands = append(app.Commands, commands.CreateMapboxCommands()...)
 
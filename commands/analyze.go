@@ -1,8 +1,10 @@
 package commands
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
+	"sync/atomic"
 
 	"github.com/statecrafthq/borg/commands/ops"
 	"github.com/statecrafthq/borg/geometry"
@@ -25,6 +27,26 @@ func analyzeDataset(c *cli.Context) error {
 		return e
 	}
 
+	//
+	// Footprints
+	//
+
+	footprints := ops.DefaultFootprints()
+	if c.String("footprints") != "" {
+		footprints, e = ops.LoadFootprints(c.String("footprints"))
+		if e != nil {
+			return e
+		}
+	}
+
+	inscribed := c.String("inscribed")
+	if inscribed == "" {
+		inscribed = ops.RotationAny
+	}
+	if inscribed != ops.RotationAny && inscribed != ops.RotationAligned {
+		return cli.NewExitError("Inscribed rectangle rotation should be 'any' or 'aligned'", 1)
+	}
+
 	//
 	// Stats counter
 	//
@@ -38,8 +60,7 @@ func analyzeDataset(c *cli.Context) error {
 	trianglesCount := 0
 	rectangleCount := 0
 	fourPointCount := 0
-	element1 := 0
-	element2 := 0
+	fitsCount := make([]int32, len(footprints))
 
 	e = ops.RecordTransformer(src, dst, func(row map[string]interface{}) (map[string]interface{}, error) {
 		totalCount++
@@ -56,7 +77,6 @@ func analyzeDataset(c *cli.Context) error {
 			if len(projected.Polygons) > 1 {
 				multiCount++
 				notConvex++
-				notAnalyzed++
 
 				extras.AppendString("shape_type", "miltipolygon")
 			} else {
@@ -105,47 +125,121 @@ func analyzeDataset(c *cli.Context) error {
 					emptyCount++
 					extras.AppendString("shape_type", "broken")
 				}
+			}
+
+			// Only valid geometry is measured and laid out
+			valid := len(projected.Polygons) > 0
+			for _, p := range projected.Polygons {
+				if len(p.Polygon) < 3 || !p.IsSimple() {
+					valid = false
+				}
+			}
+
+			if valid {
+				//
+				// Shape metrics
+				//
+
+				metrics := projected.Metrics()
+				extras.AppendFloat("perimeter", metrics.Perimeter)
+				extras.AppendFloat("hull_area", metrics.HullArea)
+				extras.AppendFloat("convexity", metrics.Convexity)
+				extras.AppendFloat("compactness", metrics.Compactness)
+				extras.AppendFloat("elongation", metrics.Elongation)
+				extras.AppendFloat("mbr_width", metrics.BoundingRectangle.Width)
+				extras.AppendFloat("mbr_length", metrics.BoundingRectangle.Length)
+				extras.AppendFloat("mbr_angle", metrics.BoundingRectangle.Angle)
+				extras.AppendFloat("frontage_estimate", metrics.Frontage)
+				extras.AppendFloat("depth_estimate", metrics.Depth)
+
+				//
+				// Largest inscribed rectangle
+				//
+
+				hasRect := false
+				var rect geometry.Rectangle2D
+				for _, poly := range projected.Polygons {
+					ok, r := poly.MaxInscribedRectangle(inscribed == ops.RotationAligned)
+					if ok && (!hasRect || r.Area() > rect.Area()) {
+						hasRect = true
+						rect = r
+					}
+				}
+				if hasRect {
+					center := rect.Center.Unproject(proj)
+					extras.AppendFloat("inscribed_width", rect.Width)
+					extras.AppendFloat("inscribed_length", rect.Length)
+					extras.AppendFloat("inscribed_angle", rect.Angle)
+					extras.AppendFloat("inscribed_lon", center.Longitude)
+					extras.AppendFloat("inscribed_lat", center.Latitude)
+				}
 
 				//
 				// Building Fitting
 				//
 
-				// Kassita-1: 12ft x 35ft (3.6576 x 10.668)
-				// Kassita-2: 10ft x 35ft (3.048  x 12.192)
-				kassita1 := ops.LayoutRectangle(poly, 3.6576, 10.668)
-				kassita2 := ops.LayoutRectangle(poly, 3.048, 12.192)
+				// Street facing edges from frontage command
+				var streetFacing [][]bool
+				if ok, edges := extras.GetEnum("frontage_edges"); ok {
+					if gs, ok := row["$geometry_src"]; ok {
+						// Edges are stored for source geometry, geometry could be simplified by finalize
+						srcProjected := geometry.NewGeoMultipolygon(utils.ParseFloat4(gs.([]interface{}))).Project(proj)
+						if sf := ops.ParseStreetFacing(srcProjected, edges); sf != nil {
+							streetFacing = ops.MapStreetFacing(srcProjected, sf, projected)
+						}
+					} else {
+						streetFacing = ops.ParseStreetFacing(projected, edges)
+					}
+				}
 
-				if kassita1.Analyzed || kassita2.Analyzed {
+				layouts := make([]ops.Layout, len(footprints))
+				analyzed := false
+				for i, f := range footprints {
+					layouts[i] = ops.LayoutFootprintMulti(projected, f, streetFacing)
+					if layouts[i].Analyzed {
+						analyzed = true
+					}
+				}
+
+				if analyzed {
 					extras.AppendString("analyzed", "true")
 				} else {
 					extras.AppendString("analyzed", "false")
 					notAnalyzed++
 				}
 
-				if kassita1.Analyzed && kassita1.Fits {
-					extras.AppendString("project_kassita1", "true")
-					if kassita1.HasLocation {
-						extras.AppendFloat("project_kassita1_angle", kassita1.Angle)
-						center := kassita1.Center.Unproject(proj)
-						extras.AppendFloat("project_kassita1_lon", center.Longitude)
-						extras.AppendFloat("project_kassita1_lat", center.Latitude)
+				for i, f := range footprints {
+					layout := layouts[i]
+					key := "project_" + f.Name
+					if layout.HasEnvelope && (f.Setbacks.Front > 0 || f.Setbacks.Interior > 0) {
+						envelope, e := json.Marshal(layout.Envelope.Unproject(proj).Serialize())
+						if e != nil {
+							return nil, e
+						}
+						extras.AppendFloat(key+"_envelope_area", layout.Envelope.Area())
+						extras.AppendString(key+"_envelope", string(envelope))
 					}
-					element1++
-				} else {
-					extras.AppendString("project_kassita1", "false")
-				}
-
-				if kassita2.Analyzed && kassita2.Fits {
-					extras.AppendString("project_kassita2", "true")
-					if kassita2.HasLocation {
-						extras.AppendFloat("project_kassita2_angle", kassita2.Angle)
-						center := kassita2.Center.Unproject(proj)
-						extras.AppendFloat("project_kassita2_lon", center.Longitude)
-						extras.AppendFloat("project_kassita2_lat", center.Latitude)
+					if layout.Analyzed && layout.Fits {
+						extras.AppendString(key, "true")
+						if layout.HasLocation {
+							extras.AppendFloat(key+"_angle", layout.Angle)
+							center := layout.Center.Unproject(proj)
+							extras.AppendFloat(key+"_lon", center.Longitude)
+							extras.AppendFloat(key+"_lat", center.Latitude)
+						}
+						if len(projected.Polygons) > 1 {
+							extras.AppendInt(key+"_component", int32(layout.Component))
+						}
+						atomic.AddInt32(&fitsCount[i], 1)
+					} else {
+						extras.AppendString(key, "false")
 					}
-					element2++
-				} else {
-					extras.AppendString("project_kassita2", "false")
+				}
+			} else {
+				notAnalyzed++
+				extras.AppendString("analyzed", "false")
+				for _, f := range footprints {
+					extras.AppendString("project_"+f.Name, "false")
 				}
 			}
 		} else {
@@ -172,8 +266,9 @@ func analyzeDataset(c *cli.Context) error {
 	fmt.Printf("-- With Holes: %d\n", withHolesCount)
 	fmt.Printf("-- Complex: %d\n", notConvex)
 	fmt.Printf("-- Total Not Analyzed: %d\n", notAnalyzed)
-	fmt.Printf("-- Element-1: %d\n", element1)
-	fmt.Printf("-- Element-2: %d\n", element2)
+	for i, f := range footprints {
+		fmt.Printf("-- Fits %s: %d\n", f.Name, fitsCount[i])
+	}
 	return nil
 }
 
@@ -191,6 +286,14 @@ func CreateAnalyzeCommands() []cli.Command {
 					Name:  "destination, dst",
 					Usage: "path to destination file",
 				},
+				cli.StringFlag{
+					Name:  "footprints",
+					Usage: "path to JSON catalog of building footprints",
+				},
+				cli.StringFlag{
+					Name:  "inscribed",
+					Usage: "rotation of largest inscribed rectangle: 'any' or 'aligned' to parcel edges",
+				},
 				cli.BoolFlag{
 					Name:  "force, f",
 					Usage: "Overwrite file if exists",
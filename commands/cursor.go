@@ -6,6 +6,7 @@ import (
 	"gopkg.in/kyokomi/emoji.v1"
 
 	"github.com/statecrafthq/borg/commands/ops"
+	"github.com/statecrafthq/borg/utils"
 	"github.com/urfave/cli"
 )
 
@@ -18,6 +19,10 @@ func cursorGet(c *cli.Context) error {
 	if out == "" {
 		return cli.NewExitError("Output is not provided", 1)
 	}
+	comparison, err := utils.NewGeometryComparison(c.String("geometry-compare"), c.Float64("geometry-tolerance"))
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
 	if key == "" {
 		return cli.NewExitError("Key file name is not provided", 1)
 	}
@@ -29,7 +34,6 @@ func cursorGet(c *cli.Context) error {
 	}
 
 	var cursor *ops.CurrentSyncStatus
-	var err error
 
 	// Latest cursor
 	latestCursor, err := ops.ReadStatus("imports/" + dataset + "/CURRENT")
@@ -85,7 +89,7 @@ func cursorGet(c *cli.Context) error {
 
 			// Build diff
 			emoji.Println(":file_cabinet: Diffing datasets")
-			err = doDiff("_processed.ols", "_latest.ols", out, false)
+			err = doDiff("_processed.ols", "_latest.ols", out, false, comparison, nil)
 			if err != nil {
 				return err
 			}
@@ -155,6 +159,8 @@ func CreateCursorCommands() []cli.Command {
 							Name:  "reset",
 							Usage: "Resetting cursor",
 						},
+						geometryCompareFlag,
+						geometryToleranceFlag,
 					},
 					Action: func(c *cli.Context) error {
 						return cursorGet(c)
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sort"
 
 	"github.com/statecrafthq/borg/commands/ops"
 	"github.com/statecrafthq/borg/utils"
@@ -29,7 +30,7 @@ func writeRecord(writer *bufio.Writer, new map[string]interface{}) error {
 	return nil
 }
 
-func doDiff(src string, updated string, out string, ignoreRemoved bool) error {
+func doDiff(src string, updated string, out string, ignoreRemoved bool, comparison utils.GeometryComparison, lineage *ops.LineageTracker) error {
 	//
 	// Preflight operations
 	//
@@ -46,9 +47,10 @@ func doDiff(src string, updated string, out string, ignoreRemoved bool) error {
 	// Diffing
 	//
 
+	tombstones := 0
 	err := ops.DiffReader(src, updated, func(srcLine *map[string]interface{}, updLine *map[string]interface{}) error {
 		if srcLine != nil && updLine != nil {
-			changed, e := utils.IsChanged(*srcLine, *updLine)
+			changed, e := utils.IsChangedWith(*srcLine, *updLine, comparison)
 			if e != nil {
 				return e
 			}
@@ -62,13 +64,22 @@ func doDiff(src string, updated string, out string, ignoreRemoved bool) error {
 				// Record is same
 			}
 		} else if srcLine != nil {
-			// Throw if there are missing record
-			fmt.Println("Record was removed!")
-			fmt.Println(srcLine)
+			if lineage != nil {
+				lineage.Removed(*srcLine)
+			}
+
+			// Record was removed
 			if !ignoreRemoved {
-				return cli.NewExitError("Record was removed!", 1)
+				e = writeRecord(writer, ops.NewTombstone(*srcLine))
+				if e != nil {
+					return e
+				}
+				tombstones++
 			}
 		} else if updLine != nil {
+			if lineage != nil {
+				lineage.Added(*updLine)
+			}
 			e = writeRecord(writer, *updLine)
 			if e != nil {
 				return e
@@ -88,6 +99,78 @@ func doDiff(src string, updated string, out string, ignoreRemoved bool) error {
 		return e
 	}
 
+	if tombstones > 0 {
+		fmt.Printf("-- Removed: %d\n", tombstones)
+	}
+
+	return nil
+}
+
+func doDiffReport(src string, updated string, out string, comparison utils.GeometryComparison, lineage *ops.LineageTracker) error {
+	dstFile, e := os.Create(out)
+	if e != nil {
+		return e
+	}
+	defer dstFile.Close()
+
+	writer := bufio.NewWriter(dstFile)
+
+	//
+	// Building change report
+	//
+
+	summary := ops.NewChangeSummary()
+	err := ops.DiffReader(src, updated, func(srcLine *map[string]interface{}, updLine *map[string]interface{}) error {
+		change, e := ops.CompareRecords(srcLine, updLine, comparison)
+		if e != nil {
+			return e
+		}
+		summary.Add(change)
+		if change == nil {
+			return nil
+		}
+		if lineage != nil {
+			if srcLine == nil {
+				lineage.Added(*updLine)
+			} else if updLine == nil {
+				lineage.Removed(*srcLine)
+			}
+		}
+		bytes, e := json.Marshal(change)
+		if e != nil {
+			return e
+		}
+		_, e = writer.Write(bytes)
+		if e != nil {
+			return e
+		}
+		_, e = writer.WriteString("\n")
+		return e
+	})
+	if err != nil {
+		return err
+	}
+	e = writer.Flush()
+	if e != nil {
+		return e
+	}
+
+	//
+	// Summary
+	//
+
+	fmt.Printf("-- Unchanged: %d\n", summary.Unchanged)
+	for _, k := range []string{ops.ChangeAdded, ops.ChangeRemoved, ops.ChangeGeometry, ops.ChangeRetired, ops.ChangeDisplayID, ops.ChangeExtras} {
+		fmt.Printf("-- %s: %d\n", k, summary.Kinds[k])
+	}
+	keys := make([]string, 0)
+	for k := range summary.Keys {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Printf("--   %s: %d\n", k, summary.Keys[k])
+	}
 	return nil
 }
 
@@ -107,7 +190,32 @@ func diff(c *cli.Context) error {
 		return cli.NewExitError("You should provide output file", 1)
 	}
 
-	return doDiff(src, updated, out, ignoreRemoved)
+	comparison, e := utils.NewGeometryComparison(c.String("geometry-compare"), c.Float64("geometry-tolerance"))
+	if e != nil {
+		return cli.NewExitError(e.Error(), 1)
+	}
+
+	lineageEvents := c.String("lineage-events")
+	var lineage *ops.LineageTracker
+	if lineageEvents != "" {
+		lineage = ops.NewLineageTracker()
+	}
+
+	if c.Bool("report") {
+		e = doDiffReport(src, updated, out, comparison, lineage)
+	} else {
+		e = doDiff(src, updated, out, ignoreRemoved, comparison, lineage)
+	}
+	if e != nil {
+		return e
+	}
+
+	if lineage != nil {
+		events := lineage.Detect(c.Float64("lineage-overlap"), "").Events
+		fmt.Printf("-- Lineage events: %d\n", len(events))
+		return ops.WriteLineageEvents(lineageEvents, events)
+	}
+	return nil
 }
 
 func CreateDiffCommands() []cli.Command {
@@ -130,8 +238,19 @@ func CreateDiffCommands() []cli.Command {
 				},
 				cli.BoolFlag{
 					Name:  "ignore-removed",
-					Usage: "Ignore removed records",
+					Usage: "Don't write tombstones of removed records",
+				},
+				cli.BoolFlag{
+					Name:  "report",
+					Usage: "Write change report with changed fields instead of updated records",
+				},
+				cli.StringFlag{
+					Name:  "lineage-events",
+					Usage: "Path to lineage events file for removed and added records",
 				},
+				lineageOverlapFlag,
+				geometryCompareFlag,
+				geometryToleranceFlag,
 			},
 			Action: func(c *cli.Context) error {
 				return diff(c)
@@ -2,22 +2,32 @@ package commands
 
 import (
 	"fmt"
+	"math"
+	"strconv"
+	"sync/atomic"
 
 	"github.com/statecrafthq/borg/commands/ops"
+	"github.com/statecrafthq/borg/geometry"
 
 	"github.com/statecrafthq/borg/utils"
 	"github.com/urfave/cli"
+	emoji "gopkg.in/kyokomi/emoji.v1"
 )
 
 func doFinalize(c *cli.Context) error {
 	src := c.String("src")
 	dst := c.String("dst")
+	tolerance := c.Float64("tolerance")
+	reportPath := c.String("report")
 	if src == "" {
 		return cli.NewExitError("You should provide source file", 1)
 	}
 	if dst == "" {
 		return cli.NewExitError("You should provide destination file", 1)
 	}
+	if tolerance <= 0 {
+		return cli.NewExitError("Tolerance should be positive", 1)
+	}
 
 	//
 	// Tear Up
@@ -27,11 +37,79 @@ func doFinalize(c *cli.Context) error {
 	if e != nil {
 		return e
 	}
+	var report *ops.CSVReport
+	if reportPath != "" {
+		e = utils.AssumeNotExists(reportPath, c.Bool("force"))
+		if e != nil {
+			return e
+		}
+		report, e = ops.NewCSVReport(reportPath, []string{"id", "vertices_before", "vertices_after", "area_before", "area_after", "area_change"})
+		if e != nil {
+			return e
+		}
+		defer report.Close()
+	}
+
+	if c.Bool("shared-arcs") {
+		return finalizeSharedArcs(c, src, dst, tolerance, report)
+	}
+	if c.String("topojson") != "" {
+		return cli.NewExitError("TopoJSON export requires --shared-arcs", 1)
+	}
+
+	//
+	// Repair
+	//
+
+	emoji.Println(":wrench: Repairing shapes")
+	repaired := make(map[string][][][][]float64)
+	shapes := make([][][][][]float64, 0)
+	e = ops.RecordReader(src, func(row map[string]interface{}) error {
+		geom, ok := row["geometry"]
+		if !ok {
+			return nil
+		}
+
+		// Already optimized shapes are kept as is
+		if _, ok := row["$geometry_src"]; ok {
+			shapes = append(shapes, utils.ParseFloat4(geom.([]interface{})))
+			return nil
+		}
+
+		coords, e := utils.PolygonRepair(utils.ParseFloat4(geom.([]interface{})))
+		if e != nil {
+			fmt.Println(row)
+			fmt.Println(e)
+			return nil
+		}
+		repaired[row["id"].(string)] = coords
+		shapes = append(shapes, coords)
+		return nil
+	})
+	if e != nil {
+		return e
+	}
+
+	//
+	// Shared vertices
+	//
+
+	shared := make(map[ops.VertexKey]bool)
+	if !c.Bool("no-topology") {
+		emoji.Println(":mag: Searching for shared vertices")
+		shared = ops.SharedVertices(shapes)
+	}
+	shapes = nil
+	locked := func(p []float64) bool {
+		return shared[ops.NewVertexKey(p)]
+	}
 
 	//
 	// Main Cycle
 	//
 
+	var verticesBefore int64
+	var verticesAfter int64
 	e = ops.RecordTransformer(src, dst, func(row map[string]interface{}) (map[string]interface{}, error) {
 		if geom, ok := row["geometry"]; ok {
 
@@ -40,34 +118,49 @@ func doFinalize(c *cli.Context) error {
 				return row, nil
 			}
 
-			// Convert types
+			// Shapes that failed to repair are left untouched
 			src := geom
-			coords := utils.ParseFloat4(geom.([]interface{}))
-
-			// Repair
-			repaired, e := utils.PolygonRepair(coords)
-			if e != nil {
-				fmt.Println(row)
-				fmt.Println(e)
+			coords, ok := repaired[row["id"].(string)]
+			if !ok {
+				coords = utils.ParseFloat4(geom.([]interface{}))
 			} else {
-				coords = repaired
-
-				// Measure area
-				// areas = append(areas, utils.MeasureArea(coords))
+				before := coords
 
 				// Optimize
-				coords = ops.OptimizePolygon(coords)
+				var stats ops.SimplifyStats
+				coords, stats = ops.SimplifyPolygon(coords, tolerance, locked)
 
 				// Repair again
 				repairedAgain, e := utils.PolygonRepair(coords)
 				if e != nil {
 					fmt.Println(row)
-					fmt.Println(repaired)
+					fmt.Println(before)
 					fmt.Println(coords)
 					fmt.Println(e)
 				} else {
 					coords = repairedAgain
 				}
+
+				// Report
+				atomic.AddInt64(&verticesBefore, int64(stats.VerticesBefore))
+				atomic.AddInt64(&verticesAfter, int64(stats.VerticesAfter))
+				if report != nil {
+					change := 0.0
+					if stats.AreaBefore > 0 {
+						change = (stats.AreaAfter - stats.AreaBefore) / stats.AreaBefore
+					}
+					e = report.Write([]string{
+						row["id"].(string),
+						strconv.Itoa(stats.VerticesBefore),
+						strconv.Itoa(stats.VerticesAfter),
+						strconv.FormatFloat(stats.AreaBefore, 'f', 2, 64),
+						strconv.FormatFloat(stats.AreaAfter, 'f', 2, 64),
+						strconv.FormatFloat(change, 'f', 6, 64),
+					})
+					if e != nil {
+						return nil, e
+					}
+				}
 			}
 
 			// Save updated geometry
@@ -80,6 +173,163 @@ func doFinalize(c *cli.Context) error {
 		return e
 	}
 
+	emoji.Printf(":bar_chart: Stats:\n")
+	fmt.Printf("-- Shared vertices: %d\n", len(shared))
+	fmt.Printf("-- Vertices before: %d\n", verticesBefore)
+	fmt.Printf("-- Vertices after: %d\n", verticesAfter)
+	return nil
+}
+
+func finalizeSharedArcs(c *cli.Context, src string, dst string, tolerance float64, report *ops.CSVReport) error {
+	topojson := c.String("topojson")
+	if topojson != "" {
+		e := utils.AssumeNotExists(topojson, c.Bool("force"))
+		if e != nil {
+			return e
+		}
+	}
+
+	//
+	// Loading all shapes
+	//
+
+	emoji.Println(":file_cabinet: Loading and repairing shapes")
+	ids := make([]string, 0)
+	shapes := make([][][][][]float64, 0)
+	locked := make([]int, 0)
+	minLat := 10000.0
+	minLon := 10000.0
+	maxLat := -10000.0
+	maxLon := -10000.0
+	e := ops.RecordReader(src, func(row map[string]interface{}) error {
+		geom, ok := row["geometry"]
+		if !ok {
+			return nil
+		}
+		coords := utils.ParseFloat4(geom.([]interface{}))
+
+		// Already optimized shapes and shapes that failed to repair are kept as is
+		if _, ok := row["$geometry_src"]; ok {
+			locked = append(locked, len(shapes))
+		} else {
+			repaired, e := utils.PolygonRepair(coords)
+			if e != nil {
+				fmt.Println(row)
+				fmt.Println(e)
+				locked = append(locked, len(shapes))
+			} else {
+				coords = repaired
+			}
+		}
+		b := geometry.NewGeoMultipolygon(coords).Bounds()
+		minLat = math.Min(minLat, b.MinLatitude)
+		minLon = math.Min(minLon, b.MinLongitude)
+		maxLat = math.Max(maxLat, b.MaxLatitude)
+		maxLon = math.Max(maxLon, b.MaxLongitude)
+		ids = append(ids, row["id"].(string))
+		shapes = append(shapes, coords)
+		return nil
+	})
+	if e != nil {
+		return e
+	}
+
+	//
+	// Simplifying arcs
+	//
+
+	emoji.Println(":scissors: Simplifying shared arcs")
+	proj := geometry.PickProjection(geometry.BoundsGeo{MinLatitude: minLat, MinLongitude: minLon, MaxLatitude: maxLat, MaxLongitude: maxLon}, geometry.MaxProjectionDistortion)
+	topology := ops.BuildTopology(shapes)
+	for _, i := range locked {
+		topology.Lock(i)
+	}
+	topology.Simplify(tolerance, proj)
+	indexes := make(map[string]int)
+	for i, id := range ids {
+		indexes[id] = i
+	}
+	if topojson != "" {
+		emoji.Println(":floppy_disk: Writing TopoJSON")
+		e = topology.WriteTopoJSON(topojson, ids)
+		if e != nil {
+			return e
+		}
+	}
+
+	//
+	// Writing shapes
+	//
+
+	var verticesBefore int64
+	var verticesAfter int64
+	e = ops.RecordTransformer(src, dst, func(row map[string]interface{}) (map[string]interface{}, error) {
+		i, ok := indexes[row["id"].(string)]
+		if !ok {
+			return row, nil
+		}
+
+		// Check if already optimized
+		if _, ok := row["$geometry_src"]; ok {
+			return row, nil
+		}
+
+		// Repair again
+		coords := topology.Shape(i)
+		repaired, e := utils.PolygonRepair(coords)
+		if e != nil {
+			fmt.Println(row)
+			fmt.Println(coords)
+			fmt.Println(e)
+		} else {
+			coords = repaired
+		}
+		row["$geometry_src"] = row["geometry"]
+		row["geometry"] = coords
+
+		before := 0
+		after := 0
+		for _, poly := range shapes[i] {
+			for _, ring := range poly {
+				before += len(ring)
+			}
+		}
+		for _, poly := range coords {
+			for _, ring := range poly {
+				after += len(ring)
+			}
+		}
+		atomic.AddInt64(&verticesBefore, int64(before))
+		atomic.AddInt64(&verticesAfter, int64(after))
+		if report != nil {
+			areaBefore := math.Abs(geometry.NewGeoMultipolygon(shapes[i]).Area())
+			areaAfter := math.Abs(geometry.NewGeoMultipolygon(coords).Area())
+			change := 0.0
+			if areaBefore > 0 {
+				change = (areaAfter - areaBefore) / areaBefore
+			}
+			e := report.Write([]string{
+				row["id"].(string),
+				strconv.Itoa(before),
+				strconv.Itoa(after),
+				strconv.FormatFloat(areaBefore, 'f', 2, 64),
+				strconv.FormatFloat(areaAfter, 'f', 2, 64),
+				strconv.FormatFloat(change, 'f', 6, 64),
+			})
+			if e != nil {
+				return nil, e
+			}
+		}
+		return row, nil
+	})
+	if e != nil {
+		return e
+	}
+
+	emoji.Printf(":bar_chart: Stats:\n")
+	fmt.Printf("-- Arcs: %d\n", len(topology.Arcs))
+	fmt.Printf("-- Vertices before: %d\n", verticesBefore)
+	fmt.Printf("-- Vertices after: %d\n", verticesAfter)
 	return nil
 }
 
@@ -97,6 +347,27 @@ func CreateFinalizeCommands() []cli.Command {
 					Name:  "dst",
 					Usage: "Destination dataset",
 				},
+				cli.Float64Flag{
+					Name:  "tolerance",
+					Value: 1,
+					Usage: "Simplification tolerance in meters",
+				},
+				cli.BoolFlag{
+					Name:  "no-topology",
+					Usage: "Allow simplification of vertices shared with neighbors",
+				},
+				cli.BoolFlag{
+					Name:  "shared-arcs",
+					Usage: "Simplify shared boundaries once for the whole dataset",
+				},
+				cli.StringFlag{
+					Name:  "topojson",
+					Usage: "Path to TopoJSON export of simplified dataset (requires --shared-arcs)",
+				},
+				cli.StringFlag{
+					Name:  "report",
+					Usage: "Path to CSV report of changes for each record",
+				},
 				cli.BoolFlag{
 					Name:  "force, f",
 					Usage: "Overwrite file if exists",
@@ -0,0 +1,198 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sync/atomic"
+
+	"github.com/statecrafthq/borg/commands/ops"
+	"github.com/statecrafthq/borg/geometry"
+	"github.com/statecrafthq/borg/utils"
+	"github.com/urfave/cli"
+	emoji "gopkg.in/kyokomi/emoji.v1"
+)
+
+func projectLines(lines []geometry.LineStringGeo) (*geometry.Projection, []geometry.LineString2D) {
+	minLat := 10000.0
+	minLon := 10000.0
+	maxLat := -10000.0
+	maxLon := -10000.0
+	for _, l := range lines {
+		for _, p := range l {
+			if p.Latitude > maxLat {
+				maxLat = p.Latitude
+			}
+			if p.Longitude > maxLon {
+				maxLon = p.Longitude
+			}
+			if p.Latitude < minLat {
+				minLat = p.Latitude
+			}
+			if p.Longitude < minLon {
+				minLon = p.Longitude
+			}
+		}
+	}
+	proj := geometry.PickProjection(geometry.BoundsGeo{MinLatitude: minLat, MinLongitude: minLon, MaxLatitude: maxLat, MaxLongitude: maxLon}, geometry.MaxProjectionDistortion)
+	res := make([]geometry.LineString2D, 0)
+	for _, l := range lines {
+		projected := make([]geometry.Point2D, 0)
+		for _, p := range l {
+			projected = append(projected, p.Project(proj))
+		}
+		res = append(res, projected)
+	}
+	return proj, res
+}
+
+func frontage(c *cli.Context) error {
+	src := c.String("src")
+	dst := c.String("dst")
+	blocks := c.String("blocks")
+	streetsPath := c.String("streets")
+	if src == "" {
+		return cli.NewExitError("You should provide source file", 1)
+	}
+	if dst == "" {
+		return cli.NewExitError("You should provide dest file", 1)
+	}
+	if (blocks == "") == (streetsPath == "") {
+		return cli.NewExitError("You should provide either blocks or streets file", 1)
+	}
+	e := utils.AssumeNotExists(dst, c.Bool("force"))
+	if e != nil {
+		return e
+	}
+
+	//
+	// Loading streets
+	//
+
+	lines := make([]geometry.LineStringGeo, 0)
+	distance := c.Float64("distance")
+	if blocks != "" {
+		emoji.Println(":file_cabinet: Loading blocks")
+		if distance <= 0 {
+			distance = 1
+		}
+		e = ops.RecordReader(blocks, func(row map[string]interface{}) error {
+			if g, ok := row["geometry"]; ok {
+				for _, p := range geometry.NewGeoMultipolygon(utils.ParseFloat4(g.([]interface{}))).Polygons {
+					lines = append(lines, p.LineStrings...)
+				}
+			}
+			return nil
+		})
+		if e != nil {
+			return e
+		}
+	} else {
+		emoji.Println(":file_cabinet: Loading street centerlines")
+		if distance <= 0 {
+			distance = 15
+		}
+		data, e := ioutil.ReadFile(streetsPath)
+		if e != nil {
+			return e
+		}
+		coords, e := utils.LoadLineStrings(data)
+		if e != nil {
+			return e
+		}
+		for _, l := range coords {
+			points := make([]geometry.PointGeo, 0)
+			for _, p := range l {
+				points = append(points, geometry.PointGeo{Longitude: p[0], Latitude: p[1]})
+			}
+			lines = append(lines, points)
+		}
+	}
+	if len(lines) == 0 {
+		return cli.NewExitError("No streets found", 1)
+	}
+	proj, projected := projectLines(lines)
+	streets := ops.NewStreets(projected, blocks != "")
+
+	//
+	// Detecting frontage
+	//
+
+	var withFrontage int32
+	var cornerLots int32
+	e = ops.RecordTransformer(src, dst, func(row map[string]interface{}) (map[string]interface{}, error) {
+		extras, e := ops.LoadExtras(row["extras"])
+		if e != nil {
+			return row, e
+		}
+		if g, ok := row["geometry"]; ok {
+			// Edges are always detected for source geometry that is not changed by finalize
+			if gs, ok := row["$geometry_src"]; ok {
+				g = gs
+			}
+			multipoly := geometry.NewGeoMultipolygon(utils.ParseFloat4(g.([]interface{}))).Project(proj)
+			res := streets.DetectFrontage(multipoly, distance)
+			extras.AppendFloat("frontage", res.Length)
+			extras.AppendInt("frontage_sides", int32(res.Sides))
+			extras.AppendEnum("frontage_edges", ops.SerializeStreetFacing(res.StreetFacing))
+			if res.Sides > 0 {
+				extras.AppendFloat("frontage_azimuth", res.Azimuth)
+				atomic.AddInt32(&withFrontage, 1)
+			} else {
+				extras.DeleteKey("frontage_azimuth")
+			}
+			if res.Sides > 1 {
+				extras.AppendString("corner_lot", "true")
+				atomic.AddInt32(&cornerLots, 1)
+			} else {
+				extras.AppendString("corner_lot", "false")
+			}
+		}
+		row["extras"] = extras
+		return row, nil
+	})
+	if e != nil {
+		return e
+	}
+	emoji.Printf(":bar_chart: Stats:\n")
+	fmt.Printf("-- With frontage: %d\n", withFrontage)
+	fmt.Printf("-- Corner lots: %d\n", cornerLots)
+	return nil
+}
+
+func CreateFrontageCommands() []cli.Command {
+	return []cli.Command{
+		{
+			Name:  "frontage",
+			Usage: "Detect street facing edges of parcels",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "source, src",
+					Usage: "Path to dataset",
+				},
+				cli.StringFlag{
+					Name:  "dest,dst",
+					Usage: "Path to destination file",
+				},
+				cli.StringFlag{
+					Name:  "blocks",
+					Usage: "Path to blocks dataset",
+				},
+				cli.StringFlag{
+					Name:  "streets",
+					Usage: "Path to GeoJSON with street centerlines",
+				},
+				cli.Float64Flag{
+					Name:  "distance",
+					Usage: "Maximum distance from parcel edge to block boundary or centerline in meters (default 1 for blocks and 15 for streets)",
+				},
+				cli.BoolFlag{
+					Name:  "force, f",
+					Usage: "Overwrite file if exists",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return frontage(c)
+			},
+		},
+	}
+}
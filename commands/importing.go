@@ -1,18 +1,16 @@
 package commands
 
 import (
-	"bufio"
-	"encoding/json"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/statecrafthq/borg/commands/ops"
 	"github.com/statecrafthq/borg/utils"
 	"github.com/urfave/cli"
-	pb "gopkg.in/cheggaaa/pb.v1"
 )
 
 func builtInQueries() map[string]string {
@@ -22,12 +20,129 @@ func builtInQueries() map[string]string {
 	return res
 }
 
+// loadQueries returns built-in queries extended with .graphql files from --queries directory, profile
+// or ~/.borg/queries
+func loadQueries(c *cli.Context) (map[string]string, error) {
+	res := builtInQueries()
+	dir := profileString(c, "queries", profile.Queries)
+	if dir == "" {
+		home, e := os.UserHomeDir()
+		if e != nil {
+			return res, nil
+		}
+		dir = filepath.Join(home, ".borg", "queries")
+		if _, e := os.Stat(dir); os.IsNotExist(e) {
+			return res, nil
+		}
+	}
+	queries, e := utils.LoadQueries(dir)
+	if e != nil {
+		return nil, e
+	}
+	for k, v := range queries {
+		res[k] = v
+	}
+	return res, nil
+}
+
+func createGraphQLClient(c *cli.Context, serverURL string) (*utils.GraphQLClient, error) {
+	client := utils.NewGraphQLClient(serverURL, profileDuration(c, "timeout"))
+	for name, value := range profile.Headers {
+		client.Headers[name] = value
+	}
+	for _, h := range c.StringSlice("header") {
+		name, value, e := utils.ParseHeader(h)
+		if e != nil {
+			return nil, cli.NewExitError(e.Error(), 1)
+		}
+		client.Headers[name] = value
+	}
+	token := profileString(c, "token", profile.Token)
+	tokenFile := profileString(c, "token-file", profile.TokenFile)
+	tokenURL := profileString(c, "oauth-token-url", profile.OAuth.TokenURL)
+	if token != "" {
+		client.Token = utils.StaticToken(token)
+	} else if tokenFile != "" {
+		t, e := utils.LoadTokenFile(tokenFile)
+		if e != nil {
+			return nil, e
+		}
+		client.Token = t
+	} else if tokenURL != "" {
+		clientID := profileString(c, "oauth-client-id", profile.OAuth.ClientID)
+		clientSecret := profileString(c, "oauth-client-secret", profile.OAuth.ClientSecret)
+		if clientID == "" || clientSecret == "" {
+			return nil, cli.NewExitError("OAuth client id and secret are required", 1)
+		}
+		client.Token = &utils.ClientCredentials{
+			TokenURL:     tokenURL,
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Scope:        profileString(c, "oauth-scope", profile.OAuth.Scope),
+			Client:       client.Client,
+		}
+	}
+	return client, nil
+}
+
+func authFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:   "token",
+			Usage:  "Bearer token",
+			EnvVar: "STATECRAFT_TOKEN",
+		},
+		cli.StringFlag{
+			Name:   "token-file",
+			Usage:  "Path to file with bearer token",
+			EnvVar: "STATECRAFT_TOKEN_FILE",
+		},
+		cli.StringFlag{
+			Name:   "oauth-token-url",
+			Usage:  "OAuth token endpoint for client credentials grant",
+			EnvVar: "STATECRAFT_OAUTH_TOKEN_URL",
+		},
+		cli.StringFlag{
+			Name:   "oauth-client-id",
+			Usage:  "OAuth client id",
+			EnvVar: "STATECRAFT_CLIENT_ID",
+		},
+		cli.StringFlag{
+			Name:   "oauth-client-secret",
+			Usage:  "OAuth client secret",
+			EnvVar: "STATECRAFT_CLIENT_SECRET",
+		},
+		cli.StringFlag{
+			Name:  "oauth-scope",
+			Usage: "OAuth scope",
+		},
+		cli.StringSliceFlag{
+			Name:  "header, H",
+			Usage: "Custom header 'Name: value'",
+		},
+		cli.DurationFlag{
+			Name:  "timeout",
+			Value: time.Minute,
+			Usage: "Request timeout",
+		},
+	}
+}
+
 func doQuery(c *cli.Context, streaming bool) error {
 
 	//
 	// Checking arguments
 	//
 
+	var queries map[string]string
+	if c.String("query") != "" || c.String("retire-query") != "" {
+		var e error
+		queries, e = loadQueries(c)
+		if e != nil {
+			return e
+		}
+	}
+
 	var body string
 	if c.String("body") != "" {
 		body = c.String("body")
@@ -38,9 +153,12 @@ func doQuery(c *cli.Context, streaming bool) error {
 		}
 		body = string(data)
 	} else if c.String("query") != "" {
-		queries := builtInQueries()
 		query := c.String("query")
-		body = queries[query]
+		if q, ok := queries[query]; ok {
+			body = q
+		} else {
+			return cli.NewExitError(fmt.Sprintf("Unknown query: %s", query), 1)
+		}
 	} else {
 		return cli.NewExitError("You should provide query or file argument", 1)
 	}
@@ -49,32 +167,55 @@ func doQuery(c *cli.Context, streaming bool) error {
 		return cli.NewExitError("Dataset for importing is not provided", 1)
 	}
 
+	// Mutation for removed records, import fails on tombstones if it is not provided
+	var retireBody string
+	if streaming {
+		if c.String("retire-file") != "" {
+			data, err := ioutil.ReadFile(c.String("retire-file"))
+			if err != nil {
+				return err
+			}
+			retireBody = string(data)
+		} else if c.String("retire-query") != "" {
+			query := c.String("retire-query")
+			if q, ok := queries[query]; ok {
+				retireBody = q
+			} else {
+				return cli.NewExitError(fmt.Sprintf("Unknown query: %s", query), 1)
+			}
+		}
+	}
+
 	//
 	// Parsing Variables
 	//
 
-	variables := c.StringSlice("variable")
-	queryVariables := make(map[string]interface{})
-	for v := range variables {
-		args := strings.SplitN(variables[v], "=", 2)
-		if len(args) < 2 {
-			return cli.NewExitError(fmt.Sprintf("Query variable mailformed: %s", variables[v]), 1)
+	queryVariables, e := utils.ParseVariables(c.StringSlice("variable"))
+	if e != nil {
+		return cli.NewExitError(e.Error(), 1)
+	}
+
+	// Records are passed in data variable while importing
+	ignored := []string{}
+	if streaming {
+		ignored = append(ignored, "data")
+	}
+	for _, q := range []string{body, retireBody} {
+		missing := utils.MissingVariables(q, queryVariables, ignored...)
+		if len(missing) > 0 {
+			return cli.NewExitError(fmt.Sprintf("Required variables are not provided: %s", strings.Join(missing, ", ")), 1)
 		}
-		queryVariables[args[0]] = args[1]
 	}
 
 	//
 	// Create Client
 	//
 
-	serverOpt := c.String("server")
-	var serverURL string
-	if serverOpt == "production" || serverOpt == "prod" {
-		serverURL = "https://api.statecrafthq.com/api"
-	} else if serverOpt == "local" {
-		serverURL = "http://localhost:9000/api"
-	} else {
-		serverURL = serverOpt
+	serverURL := resolveServer(profileString(c, "server", profile.Server))
+
+	client, e := createGraphQLClient(c, serverURL)
+	if e != nil {
+		return e
 	}
 
 	//
@@ -83,107 +224,55 @@ func doQuery(c *cli.Context, streaming bool) error {
 
 	if streaming {
 		srcFileName := c.String("source")
-		batchSize := c.Int("batch")
-		faultTolerant := c.Bool("fault-tolerant")
-
-		// Opening file
-		file, e := os.Open(srcFileName)
-		if e != nil {
-			return e
+		retries := c.Int("retries")
+		if c.Bool("fault-tolerant") && !c.IsSet("retries") {
+			retries = 10
 		}
-		defer file.Close()
-
-		// Line number
-		lines, e := utils.CountLines(file)
-		if e != nil {
-			return e
+		checkpoint := c.String("checkpoint")
+		if checkpoint == "" {
+			checkpoint = srcFileName + ".checkpoint"
 		}
-
-		//
-		// Reading And Importing
-		//
-
-		bar := pb.StartNew(lines)
-		pending := make([]map[string]interface{}, 0)
-
-		rd := bufio.NewReader(file)
-		linesRead := 0
-		for {
-			line, e := rd.ReadBytes('\n')
-			if e != nil && e != io.EOF {
-				return e
+		options := ops.ImportOptions{
+			BatchSize:  profileInt(c, "batch", profile.Batch),
+			BatchBytes: profileInt(c, "batch-bytes", profile.BatchBytes),
+			Workers:    c.Int("workers"),
+			Retries:    retries,
+			Backoff:    c.Duration("backoff"),
+			MaxBackoff: c.Duration("max-backoff"),
+			Checkpoint: checkpoint,
+			DeadLetter: c.String("dead-letter"),
+			Resume:     c.Bool("resume"),
+		}
+		importer := ops.NewImporter(options, func(records []map[string]interface{}) error {
+			variables := make(map[string]interface{})
+			for k, v := range queryVariables {
+				variables[k] = v
 			}
-			if len(line) > 0 {
-
-				var d map[string]interface{}
-				e = json.Unmarshal(line, &d)
-				if e != nil {
-					return e
-				}
-
-				// Cleanup metadata fields: everything that starts with "$"
-				toRemove := make([]string, 0)
-				for k := range d {
-					if strings.HasPrefix(k, "$") {
-						toRemove = append(toRemove, k)
-					}
-				}
-				for k := range toRemove {
-					delete(d, toRemove[k])
-				}
-
-				pending = append(pending, d)
-				linesRead = linesRead + 1
-				bar.Set(linesRead)
-				if len(pending) >= batchSize {
-					queryVariables["data"] = pending
-					if faultTolerant {
-						for {
-							_, e := utils.GraqhQLRequest(serverURL, body, queryVariables)
-							if e != nil {
-								fmt.Println(e)
-								time.Sleep(1000)
-							} else {
-								break
-							}
-						}
-					} else {
-						_, e := utils.GraqhQLRequest(serverURL, body, queryVariables)
-						if e != nil {
-							return e
-						}
-					}
-					pending = make([]map[string]interface{}, 0)
+			variables["data"] = records
+			_, e := client.Request(body, variables)
+			return e
+		})
+		if retireBody != "" {
+			importer.SetRetire(func(ids []string) error {
+				variables := make(map[string]interface{})
+				for k, v := range queryVariables {
+					variables[k] = v
 				}
-			}
-
-			if e != nil && e == io.EOF {
-				break
-			}
+				variables["data"] = ids
+				_, e := client.Request(retireBody, variables)
+				return e
+			})
 		}
-		if len(pending) > 0 {
-			queryVariables["data"] = pending
-			if faultTolerant {
-				for {
-					_, e := utils.GraqhQLRequest(serverURL, body, queryVariables)
-					if e != nil {
-						fmt.Println(e)
-						time.Sleep(1000)
-					} else {
-						break
-					}
-				}
-			} else {
-				_, e := utils.GraqhQLRequest(serverURL, body, queryVariables)
-				if e != nil {
-					return e
-				}
-			}
+		e := importer.Run(srcFileName)
+		if e != nil {
+			return e
+		}
+		if importer.DeadLetters() > 0 {
+			return cli.NewExitError(fmt.Sprintf("%d batches failed and were written to %s", importer.DeadLetters(), options.DeadLetter), 1)
 		}
-		bar.FinishPrint("Importing completed")
 	} else {
 		// Non-streaming request
-		r, e := utils.GraqhQLRequest(serverURL, body, queryVariables)
+		r, e := client.Request(body, queryVariables)
 		if e != nil {
 			return e
 		}
@@ -199,11 +288,11 @@ func CreateImportingCommands() []cli.Command {
 			Name:    "query",
 			Aliases: []string{"q"},
 			Usage:   "Query server",
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
 					Name:   "server, s",
 					Value:  "prod",
-					Usage:  "prod, local or direct URL to server",
+					Usage:  "prod, local, profile name or direct URL to server",
 					EnvVar: "STATECRAFT_SERVER",
 				},
 				cli.StringFlag{
@@ -214,11 +303,20 @@ func CreateImportingCommands() []cli.Command {
 					Name:  "file, f",
 					Usage: "Body of query from file",
 				},
+				cli.StringFlag{
+					Name:  "query",
+					Usage: "Built-in query: blocks, parcels or name of a file from queries directory",
+				},
+				cli.StringFlag{
+					Name:   "queries",
+					Usage:  "Directory with .graphql files extending built-in queries, default ~/.borg/queries",
+					EnvVar: "BORG_QUERIES",
+				},
 				cli.StringSliceFlag{
 					Name:  "variable, v",
-					Usage: "Variables to query key=value",
+					Usage: "Variables to query key=value, key:type=value (string, int, float, bool, json) or @file.json",
 				},
-			},
+			}, authFlags()...),
 			Action: func(c *cli.Context) error {
 				return doQuery(c, false)
 			},
@@ -227,11 +325,11 @@ func CreateImportingCommands() []cli.Command {
 			Name:    "import",
 			Aliases: []string{"i"},
 			Usage:   "Import dataset to server",
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
 					Name:   "server, s",
 					Value:  "prod",
-					Usage:  "prod, local or direct URL to server",
+					Usage:  "prod, local, profile name or direct URL to server",
 					EnvVar: "STATECRAFT_SERVER",
 				},
 				cli.StringFlag{
@@ -248,22 +346,71 @@ func CreateImportingCommands() []cli.Command {
 				},
 				cli.StringFlag{
 					Name:  "query",
-					Usage: "Built-in query: blocks, parcels",
+					Usage: "Built-in query: blocks, parcels or name of a file from queries directory",
+				},
+				cli.StringFlag{
+					Name:   "queries",
+					Usage:  "Directory with .graphql files extending built-in queries, default ~/.borg/queries",
+					EnvVar: "BORG_QUERIES",
 				},
 				cli.StringSliceFlag{
 					Name:  "variable, v",
-					Usage: "Variables to query key=value",
+					Usage: "Variables to query key=value, key:type=value (string, int, float, bool, json) or @file.json",
+				},
+				cli.StringFlag{
+					Name:  "retire-query",
+					Usage: "Query from queries directory for retiring removed records, receives ids in data variable",
+				},
+				cli.StringFlag{
+					Name:  "retire-file",
+					Usage: "Mutation for removed records from file",
 				},
 				cli.IntFlag{
 					Name:  "batch",
 					Value: 50,
-					Usage: "Batch size",
+					Usage: "Maximum number of records in a batch",
+				},
+				cli.IntFlag{
+					Name:  "batch-bytes",
+					Value: 1024 * 1024,
+					Usage: "Maximum size of serialized records in a batch, 0 for no limit",
 				},
 				cli.BoolFlag{
 					Name:  "fault-tolerant",
-					Usage: "Set this flag to repeat on errors",
+					Usage: "Set this flag to repeat on errors, same as --retries 10",
 				},
-			},
+				cli.IntFlag{
+					Name:  "retries",
+					Usage: "Number of retries of a failed batch",
+				},
+				cli.DurationFlag{
+					Name:  "backoff",
+					Value: time.Second,
+					Usage: "Delay before first retry, doubled on every next one",
+				},
+				cli.DurationFlag{
+					Name:  "max-backoff",
+					Value: time.Minute,
+					Usage: "Maximum delay between retries",
+				},
+				cli.IntFlag{
+					Name:  "workers",
+					Value: 1,
+					Usage: "Number of batches sent in parallel",
+				},
+				cli.StringFlag{
+					Name:  "checkpoint",
+					Usage: "Path to checkpoint file, defaults to <source>.checkpoint",
+				},
+				cli.BoolFlag{
+					Name:  "resume",
+					Usage: "Continue import from checkpoint",
+				},
+				cli.StringFlag{
+					Name:  "dead-letter",
+					Usage: "Path to file for records of batches that failed after all retries",
+				},
+			}, authFlags()...),
 			Action: func(c *cli.Context) error {
 				return doQuery(c, true)
 			},
@@ -18,8 +18,8 @@ import (
 )
 
 func doMapboxUpload(c *cli.Context) error {
-	token := c.String("token")
-	user := c.String("user")
+	token := profileString(c, "token", profile.Mapbox.Token)
+	user := profileString(c, "user", profile.Mapbox.User)
 	src := c.String("src")
 	tileset := c.String("tileset")
 	name := c.String("name")
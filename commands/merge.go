@@ -3,8 +3,11 @@ package commands
 import (
 	"bufio"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
+	"time"
 
 	"gopkg.in/kyokomi/emoji.v1"
 
@@ -84,6 +87,11 @@ func merge(c *cli.Context) error {
 	return nil
 }
 
+func recordRetired(row map[string]interface{}) bool {
+	r, ok := row["retired"].(bool)
+	return ok && r
+}
+
 func mergeOls(c *cli.Context) error {
 
 	latest := c.String("latest")
@@ -98,6 +106,24 @@ func mergeOls(c *cli.Context) error {
 	if out == "" {
 		return cli.NewExitError("You should provide output file", 1)
 	}
+	comparison, e := utils.NewGeometryComparison(c.String("geometry-compare"), c.Float64("geometry-tolerance"))
+	if e != nil {
+		return cli.NewExitError(e.Error(), 1)
+	}
+	options := ops.MergeOptions{Geometry: comparison}
+	retireAfter := c.Int("retire-after")
+	date := c.String("date")
+	if date == "" {
+		date = time.Now().Format(ops.DateFormat)
+	} else if _, e := time.Parse(ops.DateFormat, date); e != nil {
+		return cli.NewExitError("Date should be in YYYY-MM-DD format", 1)
+	}
+	if policies := c.String("policies"); policies != "" {
+		options.Policies, e = ops.LoadMergePolicies(policies)
+		if e != nil {
+			return e
+		}
+	}
 
 	// Destination
 	exist := utils.FileExists(out)
@@ -133,13 +159,30 @@ func mergeOls(c *cli.Context) error {
 	retired := 0
 	active := 0
 	total := 0
+	lineageEvents := c.String("lineage-events")
+	var lineage *ops.LineageTracker
+	if c.Bool("lineage") || lineageEvents != "" {
+		lineage = ops.NewLineageTracker()
+	}
 
 	e = ops.DiffReader(previous, latest, func(a *map[string]interface{}, b *map[string]interface{}) error {
 		total++
 		if a != nil && b != nil {
 			// Merging two records
-			merged, e := ops.Merge(*a, *b)
-			active++
+			merged, e := ops.MergeWithOptions(*a, *b, options)
+			if e != nil {
+				return e
+			}
+			ops.TrackPresent(merged, *a, date)
+			if recordRetired(merged) {
+				// Retired by driver
+				if lineage != nil && !recordRetired(*a) {
+					lineage.Removed(merged)
+				}
+				retired++
+			} else {
+				active++
+			}
 
 			// Writing to file
 			bytes, e := json.Marshal(merged)
@@ -155,8 +198,17 @@ func mergeOls(c *cli.Context) error {
 				return e
 			}
 		} else if a != nil {
-			(*a)["retired"] = true
-			retired++
+			wasRetired := recordRetired(*a)
+			ops.TrackMissing(*a, date, retireAfter)
+			if recordRetired(*a) {
+				// Missing records are matched only when they are actually retired
+				if lineage != nil && !wasRetired {
+					lineage.Removed(*a)
+				}
+				retired++
+			} else {
+				active++
+			}
 			bytes, e := json.Marshal(*a)
 			if e != nil {
 				return e
@@ -170,8 +222,18 @@ func mergeOls(c *cli.Context) error {
 				return e
 			}
 		} else if b != nil {
-			(*b)["retired"] = false
-			active++
+			if _, ok := (*b)["retired"]; !ok {
+				(*b)["retired"] = false
+			}
+			ops.TrackPresent(*b, nil, date)
+			if lineage != nil {
+				lineage.Added(*b)
+			}
+			if (*b)["retired"].(bool) {
+				retired++
+			} else {
+				active++
+			}
 			bytes, e := json.Marshal(*b)
 			if e != nil {
 				return e
@@ -195,12 +257,169 @@ func mergeOls(c *cli.Context) error {
 	if e != nil {
 		return e
 	}
+	dstFile.Close()
+
+	//
+	// Lineage
+	//
+
+	if lineage != nil {
+		emoji.Println(":family: Detecting lineage")
+		detected := lineage.Detect(c.Float64("lineage-overlap"), date)
+		if len(detected.Events) > 0 {
+			e = ops.RecordTransformer(out, out+".lineage", detected.Apply)
+			if e != nil {
+				return e
+			}
+			e = os.Rename(out+".lineage", out)
+			if e != nil {
+				return e
+			}
+		}
+		if lineageEvents != "" {
+			e = ops.WriteLineageEvents(lineageEvents, detected.Events)
+			if e != nil {
+				return e
+			}
+		}
+		fmt.Printf("-- Lineage events: %d\n", len(detected.Events))
+	}
 
 	emoji.Printf(":bar_chart: Active %d, Retired %d, Total %d\n", active, retired, total)
 
 	return nil
 }
 
+func mergeOls3(c *cli.Context) error {
+	base := c.String("base")
+	upstream := c.String("upstream")
+	local := c.String("local")
+	out := c.String("out")
+	if base == "" {
+		return cli.NewExitError("You should provide base file", 1)
+	}
+	if upstream == "" {
+		return cli.NewExitError("You should provide upstream file", 1)
+	}
+	if local == "" {
+		return cli.NewExitError("You should provide local file", 1)
+	}
+	if out == "" {
+		return cli.NewExitError("You should provide output file", 1)
+	}
+	conflictsPath := c.String("conflicts")
+	if conflictsPath == "" {
+		conflictsPath = strings.TrimSuffix(out, ".ols") + ".conflicts.ols"
+	}
+	onConflict := c.String("on-conflict")
+	policy := onConflict
+	if onConflict == "fail" {
+		policy = ops.ResolveUpstream
+	} else if onConflict != ops.ResolveUpstream && onConflict != ops.ResolveLocal && onConflict != ops.ResolveBase {
+		return cli.NewExitError("Unknown conflict policy "+onConflict, 1)
+	}
+	comparison, e := utils.NewGeometryComparison(c.String("geometry-compare"), c.Float64("geometry-tolerance"))
+	if e != nil {
+		return cli.NewExitError(e.Error(), 1)
+	}
+	options := ops.Merge3Options{Geometry: comparison, Policy: policy}
+	e = utils.AssumeNotExists(out, c.Bool("force"))
+	if e != nil {
+		return e
+	}
+	e = utils.AssumeNotExists(conflictsPath, c.Bool("force"))
+	if e != nil {
+		return e
+	}
+
+	//
+	// Preflight operations
+	//
+
+	dstFile, e := os.Create(out)
+	if e != nil {
+		return e
+	}
+	defer dstFile.Close()
+	writer := bufio.NewWriter(dstFile)
+	conflictsFile, e := os.Create(conflictsPath)
+	if e != nil {
+		return e
+	}
+	defer conflictsFile.Close()
+	conflictsWriter := bufio.NewWriter(conflictsFile)
+
+	//
+	// Merging
+	//
+
+	total := 0
+	conflicted := 0
+	conflicts := 0
+	e = ops.DiffReader3(base, upstream, local, func(b *map[string]interface{}, u *map[string]interface{}, l *map[string]interface{}) error {
+		total++
+		merged, recordConflicts, e := ops.Merge3(b, u, l, options)
+		if e != nil {
+			return e
+		}
+		if len(recordConflicts) > 0 {
+			conflicted++
+			conflicts += len(recordConflicts)
+		}
+		for _, conflict := range recordConflicts {
+			bytes, e := json.Marshal(conflict)
+			if e != nil {
+				return e
+			}
+			_, e = conflictsWriter.Write(bytes)
+			if e != nil {
+				return e
+			}
+			_, e = conflictsWriter.WriteString("\n")
+			if e != nil {
+				return e
+			}
+		}
+		return writeRecord(writer, merged)
+	})
+	if e != nil {
+		return e
+	}
+	e = writer.Flush()
+	if e != nil {
+		return e
+	}
+	e = conflictsWriter.Flush()
+	if e != nil {
+		return e
+	}
+
+	emoji.Printf(":bar_chart: Total %d, Conflicted records %d, Conflicts %d\n", total, conflicted, conflicts)
+	if conflicts > 0 && onConflict == "fail" {
+		return cli.NewExitError("Merge has conflicts, see "+conflictsPath, 1)
+	}
+
+	return nil
+}
+
+var lineageOverlapFlag = cli.Float64Flag{
+	Name:  "lineage-overlap",
+	Value: 0.5,
+	Usage: "Minimal share of a smaller shape covered by the other one to link records",
+}
+
+var geometryCompareFlag = cli.StringFlag{
+	Name:  "geometry-compare",
+	Value: utils.GeometryExact,
+	Usage: "Geometry comparison mode: exact, normalized (ignores ring start and orientation) or hausdorff",
+}
+
+var geometryToleranceFlag = cli.Float64Flag{
+	Name:  "geometry-tolerance",
+	Value: 0.01,
+	Usage: "Geometry comparison tolerance in meters",
+}
+
 func CreateMergeCommands() []cli.Command {
 	return []cli.Command{
 		{
@@ -246,11 +465,75 @@ func CreateMergeCommands() []cli.Command {
 							Name:  "force, f",
 							Usage: "Overwrite file if exists",
 						},
+						cli.IntFlag{
+							Name:  "retire-after",
+							Value: 1,
+							Usage: "Retire records only after they are missing from this number of consecutive versions",
+						},
+						cli.StringFlag{
+							Name:  "date",
+							Usage: "Date of latest version in YYYY-MM-DD format, defaults to today",
+						},
+						cli.BoolFlag{
+							Name:  "lineage",
+							Usage: "Link retired and added records by overlap with predecessors and successors extras",
+						},
+						cli.StringFlag{
+							Name:  "lineage-events",
+							Usage: "Path to lineage events file, enables lineage detection",
+						},
+						lineageOverlapFlag,
+						cli.StringFlag{
+							Name:  "policies",
+							Usage: "Path to JSON file with merge policies per extras key",
+						},
+						geometryCompareFlag,
+						geometryToleranceFlag,
 					},
 					Action: func(c *cli.Context) error {
 						return mergeOls(c)
 					},
 				},
+				{
+					Name:  "ols3",
+					Usage: "Three-way merge of upstream and local changes made on top of a base dataset",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "base",
+							Usage: "Path to common base dataset",
+						},
+						cli.StringFlag{
+							Name:  "upstream",
+							Usage: "Path to upstream dataset",
+						},
+						cli.StringFlag{
+							Name:  "local",
+							Usage: "Path to dataset with local changes",
+						},
+						cli.StringFlag{
+							Name:  "out",
+							Usage: "Path to destination file",
+						},
+						cli.StringFlag{
+							Name:  "conflicts",
+							Usage: "Path to conflicts file, defaults to <out>.conflicts.ols",
+						},
+						cli.StringFlag{
+							Name:  "on-conflict",
+							Value: ops.ResolveUpstream,
+							Usage: "Conflict resolution: upstream, local, base or fail",
+						},
+						cli.BoolFlag{
+							Name:  "force, f",
+							Usage: "Overwrite file if exists",
+						},
+						geometryCompareFlag,
+						geometryToleranceFlag,
+					},
+					Action: func(c *cli.Context) error {
+						return mergeOls3(c)
+					},
+				},
 			},
 		},
 	}
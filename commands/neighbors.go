@@ -0,0 +1,269 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/statecrafthq/borg/commands/ops"
+	"github.com/statecrafthq/borg/geometry"
+	"github.com/statecrafthq/borg/utils"
+	"github.com/urfave/cli"
+	emoji "gopkg.in/kyokomi/emoji.v1"
+)
+
+func neighbors(c *cli.Context) error {
+	src := c.String("src")
+	dst := c.String("dst")
+	edges := c.String("edges")
+	groupBy := c.String("group-by")
+	groupTarget := ""
+	hasTarget := false
+	if i := strings.Index(groupBy, "="); i >= 0 {
+		groupTarget = groupBy[i+1:]
+		groupBy = groupBy[:i]
+		hasTarget = true
+	}
+	tolerance := c.Float64("tolerance")
+	minLength := c.Float64("min-length")
+	if src == "" {
+		return cli.NewExitError("You should provide source file", 1)
+	}
+	if dst == "" {
+		return cli.NewExitError("You should provide dest file", 1)
+	}
+	if hasTarget && (groupBy == "" || groupTarget == "") {
+		return cli.NewExitError("Group should be key or key=value", 1)
+	}
+	e := utils.AssumeNotExists(dst, c.Bool("force"))
+	if e != nil {
+		return e
+	}
+	if edges != "" {
+		e = utils.AssumeNotExists(edges, c.Bool("force"))
+		if e != nil {
+			return e
+		}
+	}
+
+	//
+	// Loading parcels
+	//
+
+	emoji.Println(":file_cabinet: Loading parcels")
+	ids := make([]string, 0)
+	shapesGeo := make([]geometry.MultipolygonGeo, 0)
+	groups := make([]string, 0)
+	hasGroup := make([]bool, 0)
+	minLat := 10000.0
+	minLon := 10000.0
+	maxLat := -10000.0
+	maxLon := -10000.0
+	e = ops.RecordReader(src, func(row map[string]interface{}) error {
+		g, ok := row["geometry"]
+		if !ok {
+			return nil
+		}
+		shape := geometry.NewGeoMultipolygon(utils.ParseFloat4(g.([]interface{})))
+		b := shape.Bounds()
+		if b.MaxLatitude > maxLat {
+			maxLat = b.MaxLatitude
+		}
+		if b.MaxLongitude > maxLon {
+			maxLon = b.MaxLongitude
+		}
+		if b.MinLongitude < minLon {
+			minLon = b.MinLongitude
+		}
+		if b.MinLatitude < minLat {
+			minLat = b.MinLatitude
+		}
+		group := ""
+		groupExists := false
+		if groupBy != "" {
+			extras, e := ops.LoadExtras(row["extras"])
+			if e != nil {
+				return e
+			}
+			groupExists, group = extras.GetValue(groupBy)
+
+			// Parcels without value are never grouped
+			if group == "" || (hasTarget && group != groupTarget) {
+				groupExists = false
+			}
+		}
+		ids = append(ids, row["id"].(string))
+		shapesGeo = append(shapesGeo, shape)
+		groups = append(groups, group)
+		hasGroup = append(hasGroup, groupExists)
+		return nil
+	})
+	if e != nil {
+		return e
+	}
+
+	//
+	// Building graph
+	//
+
+	emoji.Println(":link: Building adjacency graph")
+	proj := geometry.PickProjection(geometry.BoundsGeo{MinLatitude: minLat, MinLongitude: minLon, MaxLatitude: maxLat, MaxLongitude: maxLon}, geometry.MaxProjectionDistortion)
+	shapes := make([]geometry.Multipolygon2D, len(shapesGeo))
+	for i, s := range shapesGeo {
+		shapes[i] = s.Project(proj)
+	}
+	adjacency := ops.BuildAdjacency(shapes, tolerance, minLength)
+	indexes := make(map[string]int)
+	for i, id := range ids {
+		indexes[id] = i
+	}
+
+	//
+	// Assemblages
+	//
+
+	components := ops.ConnectedComponents(adjacency, func(a int, b int) bool {
+		return hasGroup[a] && hasGroup[b] && groups[a] == groups[b]
+	})
+	componentSizes := make(map[int]int)
+	componentIds := make(map[int]string)
+	for i, comp := range components {
+		componentSizes[comp]++
+		if existing, ok := componentIds[comp]; !ok || ids[i] < existing {
+			componentIds[comp] = ids[i]
+		}
+	}
+
+	//
+	// Exporting edges
+	//
+
+	edgesCount := 0
+	if edges != "" {
+		emoji.Println(":floppy_disk: Writing edge list")
+		file, e := os.Create(edges)
+		if e != nil {
+			return e
+		}
+		defer file.Close()
+		w := bufio.NewWriter(file)
+		_, e = w.WriteString("source,target,length\n")
+		if e != nil {
+			return e
+		}
+		for i, neighbors := range adjacency {
+			for _, n := range neighbors {
+				if n.Index < i {
+					continue
+				}
+				_, e = w.WriteString(ids[i] + "," + ids[n.Index] + "," + strconv.FormatFloat(n.Length, 'f', 2, 64) + "\n")
+				if e != nil {
+					return e
+				}
+				edgesCount++
+			}
+		}
+		e = w.Flush()
+		if e != nil {
+			return e
+		}
+	}
+
+	//
+	// Writing neighbors
+	//
+
+	e = ops.RecordTransformer(src, dst, func(row map[string]interface{}) (map[string]interface{}, error) {
+		i, ok := indexes[row["id"].(string)]
+		if !ok {
+			return row, nil
+		}
+		extras, e := ops.LoadExtras(row["extras"])
+		if e != nil {
+			return row, e
+		}
+		neighborIds := make([]string, 0)
+		for _, n := range adjacency[i] {
+			neighborIds = append(neighborIds, ids[n.Index])
+		}
+		extras.AppendEnum("neighbors", neighborIds)
+		extras.AppendInt("neighbors_count", int32(len(neighborIds)))
+		if groupBy != "" {
+			comp := components[i]
+			if hasGroup[i] && componentSizes[comp] > 1 {
+				extras.AppendString("assemblage_id", componentIds[comp])
+				extras.AppendInt("assemblage_size", int32(componentSizes[comp]))
+			} else {
+				extras.DeleteKey("assemblage_id")
+				extras.DeleteKey("assemblage_size")
+			}
+		}
+		row["extras"] = extras
+		return row, nil
+	})
+	if e != nil {
+		return e
+	}
+
+	assemblages := 0
+	for _, size := range componentSizes {
+		if size > 1 {
+			assemblages++
+		}
+	}
+	emoji.Printf(":bar_chart: Stats:\n")
+	fmt.Printf("-- Parcels: %d\n", len(ids))
+	if edges != "" {
+		fmt.Printf("-- Edges: %d\n", edgesCount)
+	}
+	if groupBy != "" {
+		fmt.Printf("-- Assemblages: %d\n", assemblages)
+	}
+	return nil
+}
+
+func CreateNeighborsCommands() []cli.Command {
+	return []cli.Command{
+		{
+			Name:  "neighbors",
+			Usage: "Build parcel adjacency graph",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "source, src",
+					Usage: "Path to dataset",
+				},
+				cli.StringFlag{
+					Name:  "dest,dst",
+					Usage: "Path to destination file",
+				},
+				cli.StringFlag{
+					Name:  "edges",
+					Usage: "Path to CSV edge list of adjacency graph",
+				},
+				cli.StringFlag{
+					Name:  "group-by",
+					Usage: "Extras key that should match for parcels of same assemblage, or key=value to group only parcels with this value",
+				},
+				cli.Float64Flag{
+					Name:  "tolerance",
+					Value: 0.5,
+					Usage: "Maximum distance between shared edges in meters",
+				},
+				cli.Float64Flag{
+					Name:  "min-length",
+					Value: 1,
+					Usage: "Minimum length of shared boundary in meters",
+				},
+				cli.BoolFlag{
+					Name:  "force, f",
+					Usage: "Overwrite file if exists",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return neighbors(c)
+			},
+		},
+	}
+}
@@ -6,11 +6,14 @@ import (
 	"cloud.google.com/go/storage"
 )
 
+// BucketName is a name of storage bucket for synced datasets
+var BucketName = "data.openland.com"
+
 func CreateBucket() (*storage.BucketHandle, error) {
 	ctx := context.Background()
 	client, err := storage.NewClient(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return client.Bucket("data.openland.com"), nil
+	return client.Bucket(BucketName), nil
 }
@@ -0,0 +1,213 @@
+package ops
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/statecrafthq/borg/utils"
+)
+
+// Kinds of record changes
+const (
+	ChangeAdded     = "added"
+	ChangeRemoved   = "removed"
+	ChangeGeometry  = "geometry-changed"
+	ChangeRetired   = "retired-toggled"
+	ChangeExtras    = "extras-changed"
+	ChangeDisplayID = "display-id-changed"
+)
+
+// ExtrasChange is a change of a single extras key. Old or New is nil when key is added or removed.
+type ExtrasChange struct {
+	Key string      `json:"key"`
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// RecordChange describes all changes of a single record
+type RecordChange struct {
+	ID      string         `json:"id"`
+	Changes []string       `json:"changes"`
+	Extras  []ExtrasChange `json:"extras,omitempty"`
+}
+
+// ChangeSummary counts changes across dataset
+type ChangeSummary struct {
+	Unchanged int            `json:"unchanged"`
+	Kinds     map[string]int `json:"kinds"`
+	Keys      map[string]int `json:"keys"`
+}
+
+// NewChangeSummary creates empty summary
+func NewChangeSummary() *ChangeSummary {
+	return &ChangeSummary{Kinds: make(map[string]int), Keys: make(map[string]int)}
+}
+
+// Add records change in a summary, nil change is counted as unchanged record
+func (s *ChangeSummary) Add(change *RecordChange) {
+	if change == nil {
+		s.Unchanged++
+		return
+	}
+	for _, k := range change.Changes {
+		s.Kinds[k]++
+	}
+	for _, e := range change.Extras {
+		s.Keys[e.Key]++
+	}
+}
+
+func recordID(row map[string]interface{}) string {
+	if id, ok := row["id"].(string); ok {
+		return id
+	}
+	return ""
+}
+
+func isRetired(row map[string]interface{}) bool {
+	if r, ok := row["retired"].(bool); ok {
+		return r
+	}
+	return false
+}
+
+func geometryChanged(src map[string]interface{}, dst map[string]interface{}, key string, comparison utils.GeometryComparison) (bool, error) {
+	g1, ok1 := src[key]
+	g2, ok2 := dst[key]
+	if ok1 != ok2 {
+		return true, nil
+	}
+	if !ok1 {
+		return false, nil
+	}
+	return comparison.IsChanged(g1.([]interface{}), g2.([]interface{}))
+}
+
+func displayIDChanged(src map[string]interface{}, dst map[string]interface{}) bool {
+	d1, _ := src["displayId"].([]interface{})
+	d2, _ := dst["displayId"].([]interface{})
+	if len(d1) != len(d2) {
+		return true
+	}
+	for i := range d1 {
+		if d1[i] != d2[i] {
+			return true
+		}
+	}
+	return false
+}
+
+func extrasValues(e *Extras) map[string]interface{} {
+	res := make(map[string]interface{})
+	for _, v := range e.Strings {
+		res[v.Key] = v.Value
+	}
+	for _, v := range e.Enums {
+		res[v.Key] = v.Value
+	}
+	for _, v := range e.Floats {
+		res[v.Key] = v.Value
+	}
+	for _, v := range e.Ints {
+		res[v.Key] = v.Value
+	}
+	return res
+}
+
+func isExtrasValueEqual(a interface{}, b interface{}) bool {
+	switch av := a.(type) {
+	case []string:
+		bv, ok := b.([]string)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		// Enums are compared regardless of order
+		as := append([]string{}, av...)
+		bs := append([]string{}, bv...)
+		sort.Strings(as)
+		sort.Strings(bs)
+		for i := range as {
+			if as[i] != bs[i] {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
+
+// CompareExtras returns changed extras keys sorted by key
+func CompareExtras(src *Extras, dst *Extras) []ExtrasChange {
+	v1 := extrasValues(src)
+	v2 := extrasValues(dst)
+	res := make([]ExtrasChange, 0)
+	for k, o := range v1 {
+		n, ok := v2[k]
+		if !ok {
+			res = append(res, ExtrasChange{Key: k, Old: o})
+		} else if !isExtrasValueEqual(o, n) {
+			res = append(res, ExtrasChange{Key: k, Old: o, New: n})
+		}
+	}
+	for k, n := range v2 {
+		if _, ok := v1[k]; !ok {
+			res = append(res, ExtrasChange{Key: k, New: n})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Key < res[j].Key })
+	return res
+}
+
+// CompareRecords builds a change report for two versions of a record. Any of them could be nil for
+// added or removed records. Returns nil if records are the same.
+func CompareRecords(src *map[string]interface{}, dst *map[string]interface{}, comparison utils.GeometryComparison) (*RecordChange, error) {
+	if src == nil && dst == nil {
+		return nil, errors.New("Internal inconsistency")
+	}
+	if src == nil {
+		return &RecordChange{ID: recordID(*dst), Changes: []string{ChangeAdded}}, nil
+	}
+	if dst == nil {
+		return &RecordChange{ID: recordID(*src), Changes: []string{ChangeRemoved}}, nil
+	}
+	changed, e := utils.IsChangedWith(*src, *dst, comparison)
+	if e != nil {
+		return nil, e
+	}
+	if !changed {
+		return nil, nil
+	}
+
+	res := &RecordChange{ID: recordID(*dst), Changes: []string{}}
+	geometry, e := geometryChanged(*src, *dst, "geometry", comparison)
+	if e != nil {
+		return nil, e
+	}
+	geometrySrc, e := geometryChanged(*src, *dst, "$geometry_src", comparison)
+	if e != nil {
+		return nil, e
+	}
+	if geometry || geometrySrc {
+		res.Changes = append(res.Changes, ChangeGeometry)
+	}
+	if isRetired(*src) != isRetired(*dst) {
+		res.Changes = append(res.Changes, ChangeRetired)
+	}
+	if displayIDChanged(*src, *dst) {
+		res.Changes = append(res.Changes, ChangeDisplayID)
+	}
+	extras1, e := LoadExtras((*src)["extras"])
+	if e != nil {
+		return nil, e
+	}
+	extras2, e := LoadExtras((*dst)["extras"])
+	if e != nil {
+		return nil, e
+	}
+	res.Extras = CompareExtras(extras1, extras2)
+	if len(res.Extras) > 0 {
+		res.Changes = append(res.Changes, ChangeExtras)
+	}
+	return res, nil
+}
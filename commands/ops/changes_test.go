@@ -0,0 +1,115 @@
+package ops
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/statecrafthq/borg/utils"
+)
+
+func parseRecord(t *testing.T, src string) *map[string]interface{} {
+	res := make(map[string]interface{})
+	e := json.Unmarshal([]byte(src), &res)
+	if e != nil {
+		t.Fatal(e)
+	}
+	return &res
+}
+
+func parseRecordString(src string) *map[string]interface{} {
+	res := make(map[string]interface{})
+	e := json.Unmarshal([]byte(src), &res)
+	if e != nil {
+		panic(e)
+	}
+	return &res
+}
+
+func TestCompareRecords(t *testing.T) {
+	old := parseRecord(t, `{"id":"1","geometry":[[[[0,0],[1,0],[1,1],[0,0]]]],"extras":{"strings":[{"key":"a","value":"x"}],"enums":[{"key":"e","value":["1","2"]}],"ints":[{"key":"n","value":1}]}}`)
+
+	// Same record
+	change, e := CompareRecords(old, old, utils.ExactComparison)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if change != nil {
+		t.Errorf("Expected no changes, got %v", change.Changes)
+	}
+
+	// Enum order doesn't matter
+	same := parseRecord(t, `{"id":"1","geometry":[[[[0,0],[1,0],[1,1],[0,0]]]],"extras":{"strings":[{"key":"a","value":"x"}],"enums":[{"key":"e","value":["2","1"]}],"ints":[{"key":"n","value":1}]}}`)
+	change, e = CompareRecords(old, same, utils.ExactComparison)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if change != nil {
+		t.Errorf("Expected no changes, got %v", change.Changes)
+	}
+
+	// Enums with repeated values are different
+	repeated := parseRecord(t, `{"id":"1","geometry":[[[[0,0],[1,0],[1,1],[0,0]]]],"extras":{"strings":[{"key":"a","value":"x"}],"enums":[{"key":"e","value":["1","1"]}],"ints":[{"key":"n","value":1}]}}`)
+	change, e = CompareRecords(old, repeated, utils.ExactComparison)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if change == nil || len(change.Extras) != 1 || change.Extras[0].Key != "e" {
+		t.Errorf("Expected enum change, got %v", change)
+	}
+	change, _ = CompareRecords(repeated, old, utils.ExactComparison)
+	if change == nil {
+		t.Error("Expected enum change")
+	}
+
+	// Geometry, retired and extras
+	upd := parseRecord(t, `{"id":"1","retired":true,"geometry":[[[[0,0],[2,0],[1,1],[0,0]]]],"extras":{"strings":[{"key":"a","value":"y"}],"floats":[{"key":"f","value":1.5}]}}`)
+	change, e = CompareRecords(old, upd, utils.ExactComparison)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if change == nil {
+		t.Fatal("Expected changes")
+	}
+	expected := []string{ChangeGeometry, ChangeRetired, ChangeExtras}
+	if len(change.Changes) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, change.Changes)
+	}
+	for i := range expected {
+		if change.Changes[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, change.Changes)
+		}
+	}
+	if len(change.Extras) != 4 {
+		t.Fatalf("Expected 4 extras changes, got %v", change.Extras)
+	}
+	if change.Extras[0].Key != "a" || change.Extras[0].Old != "x" || change.Extras[0].New != "y" {
+		t.Errorf("Unexpected change %v", change.Extras[0])
+	}
+	if change.Extras[1].Key != "e" || change.Extras[1].New != nil {
+		t.Errorf("Unexpected change %v", change.Extras[1])
+	}
+	if change.Extras[2].Key != "f" || change.Extras[2].Old != nil || change.Extras[2].New != 1.5 {
+		t.Errorf("Unexpected change %v", change.Extras[2])
+	}
+
+	// Added and removed
+	change, _ = CompareRecords(nil, upd, utils.ExactComparison)
+	if change.Changes[0] != ChangeAdded || change.ID != "1" {
+		t.Errorf("Expected added record, got %v", change)
+	}
+	change, _ = CompareRecords(old, nil, utils.ExactComparison)
+	if change.Changes[0] != ChangeRemoved || change.ID != "1" {
+		t.Errorf("Expected removed record, got %v", change)
+	}
+}
+
+func TestChangeSummary(t *testing.T) {
+	summary := NewChangeSummary()
+	summary.Add(nil)
+	summary.Add(&RecordChange{ID: "1", Changes: []string{ChangeAdded}})
+	summary.Add(&RecordChange{ID: "2", Changes: []string{ChangeGeometry, ChangeExtras}, Extras: []ExtrasChange{{Key: "a"}}})
+	summary.Add(&RecordChange{ID: "3", Changes: []string{ChangeExtras}, Extras: []ExtrasChange{{Key: "a"}, {Key: "b"}}})
+	if summary.Unchanged != 1 || summary.Kinds[ChangeAdded] != 1 || summary.Kinds[ChangeExtras] != 2 || summary.Keys["a"] != 2 || summary.Keys["b"] != 1 {
+		t.Errorf("Unexpected summary %v", summary)
+	}
+}
@@ -2,6 +2,8 @@ package ops
 
 import (
 	"encoding/json"
+	"strconv"
+	"strings"
 )
 
 func LoadExtras(src interface{}) (*Extras, error) {
@@ -49,6 +51,74 @@ func (e *Extras) HasKey(key string) bool {
 	return false
 }
 
+// GetEnum returns value of enum key
+func (e *Extras) GetEnum(key string) (bool, []string) {
+	for i := range e.Enums {
+		if e.Enums[i].Key == key {
+			return true, e.Enums[i].Value
+		}
+	}
+	return false, nil
+}
+
+// Types of extras values
+const (
+	ExtrasTypeString = "string"
+	ExtrasTypeEnum   = "enum"
+	ExtrasTypeFloat  = "float"
+	ExtrasTypeInt    = "int"
+)
+
+// KeyType returns type of a key or empty string if key is not present
+func (e *Extras) KeyType(key string) string {
+	for i := range e.Strings {
+		if e.Strings[i].Key == key {
+			return ExtrasTypeString
+		}
+	}
+	for i := range e.Enums {
+		if e.Enums[i].Key == key {
+			return ExtrasTypeEnum
+		}
+	}
+	for i := range e.Floats {
+		if e.Floats[i].Key == key {
+			return ExtrasTypeFloat
+		}
+	}
+	for i := range e.Ints {
+		if e.Ints[i].Key == key {
+			return ExtrasTypeInt
+		}
+	}
+	return ""
+}
+
+// GetValue returns value of any key formatted as a string
+func (e *Extras) GetValue(key string) (bool, string) {
+	for i := range e.Strings {
+		if e.Strings[i].Key == key {
+			return true, e.Strings[i].Value
+		}
+	}
+	for i := range e.Enums {
+		if e.Enums[i].Key == key {
+			return true, strings.Join(e.Enums[i].Value, ",")
+		}
+	}
+	for i := range e.Floats {
+		if e.Floats[i].Key == key {
+			return true, strconv.FormatFloat(e.Floats[i].Value, 'f', -1, 64)
+		}
+	}
+	for i := range e.Ints {
+		if e.Ints[i].Key == key {
+			return true, strconv.Itoa(int(e.Ints[i].Value))
+		}
+	}
+	return false, ""
+}
+
 func (e *Extras) DeleteKey(key string) {
 
 	// Strings
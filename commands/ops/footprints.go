@@ -0,0 +1,136 @@
+package ops
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math"
+	"regexp"
+
+	"github.com/statecrafthq/borg/geometry"
+)
+
+const (
+	// RotationAny allows footprint to be placed at any angle
+	RotationAny = "any"
+	// RotationAligned allows only placements aligned to parcel edges
+	RotationAligned = "aligned"
+)
+
+// Setbacks are required distances from footprint to parcel boundary (in meters)
+type Setbacks struct {
+	Front    float64 `json:"front"`
+	Interior float64 `json:"interior"`
+}
+
+// Footprint is a building model that is tested for fitting into a parcel
+type Footprint struct {
+	Name     string   `json:"name"`
+	Width    float64  `json:"width"`
+	Length   float64  `json:"length"`
+	Setbacks Setbacks `json:"setbacks"`
+	Rotation string   `json:"rotation"`
+}
+
+var validFootprintName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// DefaultFootprints returns models that were analyzed before catalogs were introduced
+func DefaultFootprints() []Footprint {
+	return []Footprint{
+		// Kassita-1: 12ft x 35ft (3.6576 x 10.668)
+		{Name: "kassita1", Width: 3.6576, Length: 10.668, Rotation: RotationAny},
+		// Kassita-2: 10ft x 35ft (3.048  x 12.192)
+		{Name: "kassita2", Width: 3.048, Length: 12.192, Rotation: RotationAny},
+	}
+}
+
+// LoadFootprints reads footprint catalog from JSON file
+func LoadFootprints(src string) ([]Footprint, error) {
+	data, e := ioutil.ReadFile(src)
+	if e != nil {
+		return nil, e
+	}
+	var res []Footprint
+	e = json.Unmarshal(data, &res)
+	if e != nil {
+		return nil, e
+	}
+	if len(res) == 0 {
+		return nil, errors.New("Footprint catalog is empty")
+	}
+	names := make(map[string]bool)
+	for i := range res {
+		f := &res[i]
+		if !validFootprintName.MatchString(f.Name) {
+			return nil, errors.New("Invalid footprint name '" + f.Name + "'")
+		}
+		if names[f.Name] {
+			return nil, errors.New("Duplicate footprint " + f.Name)
+		}
+		names[f.Name] = true
+		if f.Width <= 0 || f.Length <= 0 {
+			return nil, errors.New("Footprint " + f.Name + " should have positive width and length")
+		}
+		if f.Setbacks.Front < 0 || f.Setbacks.Interior < 0 {
+			return nil, errors.New("Footprint " + f.Name + " has negative setbacks")
+		}
+		if f.Rotation == "" {
+			f.Rotation = RotationAny
+		}
+		if f.Rotation != RotationAny && f.Rotation != RotationAligned {
+			return nil, errors.New("Footprint " + f.Name + " has unknown rotation '" + f.Rotation + "'")
+		}
+	}
+	return res, nil
+}
+
+// Envelope builds buildable area of a parcel by moving street facing edges inside by front setback
+// and all other edges by interior one. If street facing edges are unknown largest setback is used.
+func Envelope(poly geometry.Polygon2D, setbacks Setbacks, streetFacing []bool) (bool, geometry.Polygon2D) {
+	distances := make([]float64, len(poly.Polygon))
+	for i := range distances {
+		if streetFacing == nil {
+			distances[i] = math.Max(setbacks.Front, setbacks.Interior)
+		} else if streetFacing[i] {
+			distances[i] = setbacks.Front
+		} else {
+			distances[i] = setbacks.Interior
+		}
+	}
+	return poly.Offset(distances, setbacks.Interior)
+}
+
+// LayoutFootprint tries to place footprint into buildable envelope of a parcel
+func LayoutFootprint(poly geometry.Polygon2D, footprint Footprint, streetFacing []bool) Layout {
+	ok, envelope := Envelope(poly, footprint.Setbacks, streetFacing)
+	if !ok {
+		return Layout{Analyzed: true, Fits: false}
+	}
+	res := layoutRectangle(envelope, footprint.Width, footprint.Length, footprint.Rotation != RotationAligned)
+	if res.Analyzed {
+		res.HasEnvelope = true
+		res.Envelope = envelope
+	}
+	return res
+}
+
+// LayoutFootprintMulti tries to place footprint into each component of a multipolygon
+// and reports first component that fits it
+func LayoutFootprintMulti(multipoly geometry.Multipolygon2D, footprint Footprint, streetFacing [][]bool) Layout {
+	res := Layout{Analyzed: false}
+	for i, poly := range multipoly.Polygons {
+		var sf []bool
+		if streetFacing != nil {
+			sf = streetFacing[i]
+		}
+		l := LayoutFootprint(poly, footprint, sf)
+		if l.Analyzed {
+			l.Component = i
+			if l.Fits {
+				return l
+			}
+			res = l
+		}
+	}
+	return res
+}
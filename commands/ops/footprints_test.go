@@ -0,0 +1,65 @@
+package ops
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeCatalog(t *testing.T, body string) string {
+	f, e := ioutil.TempFile("", "footprints")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer f.Close()
+	_, e = f.WriteString(body)
+	if e != nil {
+		t.Fatal(e)
+	}
+	return f.Name()
+}
+
+func TestLoadFootprints(t *testing.T) {
+	path := writeCatalog(t, `[{"name":"cube_1","width":3,"length":9,"setbacks":{"front":1.5,"interior":1}},{"name":"cube_2","width":4,"length":8,"rotation":"aligned"}]`)
+	defer os.Remove(path)
+	res, e := LoadFootprints(path)
+	assert.NoError(t, e)
+	assert.Equal(t, 2, len(res))
+	assert.Equal(t, RotationAny, res[0].Rotation)
+	assert.Equal(t, 1.5, res[0].Setbacks.Front)
+	assert.Equal(t, RotationAligned, res[1].Rotation)
+
+	path2 := writeCatalog(t, `[{"name":"Cube","width":3,"length":9}]`)
+	defer os.Remove(path2)
+	_, e = LoadFootprints(path2)
+	assert.Error(t, e)
+
+	path3 := writeCatalog(t, `[{"name":"cube","width":3,"length":9},{"name":"cube","width":4,"length":9}]`)
+	defer os.Remove(path3)
+	_, e = LoadFootprints(path3)
+	assert.Error(t, e)
+}
+
+func TestLayoutFootprint(t *testing.T) {
+	parcel := loadParcel("[[[[-73.945989,40.663811],[-73.946223,40.663797],[-73.946215,40.663737],[-73.945982,40.663751],[-73.945989,40.663811]]]]")
+
+	layout := LayoutFootprint(parcel, DefaultFootprints()[0], nil)
+	assert.True(t, layout.Analyzed)
+	assert.True(t, layout.Fits)
+
+	// Same model but with setbacks that are wider than the lot
+	withSetbacks := DefaultFootprints()[0]
+	withSetbacks.Setbacks = Setbacks{Front: 3, Interior: 3}
+	layout = LayoutFootprint(parcel, withSetbacks, nil)
+	assert.True(t, layout.Analyzed)
+	assert.False(t, layout.Fits)
+
+	// Setbacks only on the long street facing side
+	withSetbacks.Setbacks = Setbacks{Front: 1, Interior: 0}
+	layout = LayoutFootprint(parcel, withSetbacks, []bool{true, false, false, false})
+	assert.True(t, layout.Fits)
+	assert.True(t, layout.HasEnvelope)
+	assert.True(t, layout.Envelope.Area() < parcel.Area())
+}
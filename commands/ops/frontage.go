@@ -0,0 +1,241 @@
+package ops
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/statecrafthq/borg/geometry"
+)
+
+// Maximum angle between parcel edge and street to consider them parallel
+const frontageMaxAngle = math.Pi / 9
+
+// Maximum turn between edges of a single side of a parcel
+const frontageSideAngle = math.Pi / 6
+
+// Frontage describes street facing edges of a parcel
+type Frontage struct {
+	// Street facing flags for every edge of outer ring of every component
+	StreetFacing [][]bool
+	// Total length of street facing edges
+	Length float64
+	// Number of street facing sides, more than one for corner lots
+	Sides int
+	// Azimuth of outward direction of the longest street facing side
+	Azimuth float64
+}
+
+// Streets is a spatial index of street segments: block boundaries or street centerlines
+type Streets struct {
+	index *geometry.GridIndex
+	edges []geometry.Edge2D
+}
+
+// NewStreets builds index of street segments. Lines are closed for block boundaries.
+func NewStreets(lines []geometry.LineString2D, closed bool) *Streets {
+	res := &Streets{index: geometry.NewGridIndex(50), edges: make([]geometry.Edge2D, 0)}
+	for _, l := range lines {
+		n := len(l) - 1
+		if closed {
+			n = len(l)
+		}
+		for i := 0; i < n; i++ {
+			e := geometry.Edge2D{Start: l[i], End: l[(i+1)%len(l)]}
+			res.index.Insert(e.Bounds())
+			res.edges = append(res.edges, e)
+		}
+	}
+	return res
+}
+
+func angleDiff(a float64, b float64) float64 {
+	d := math.Mod(math.Abs(a-b), 2*math.Pi)
+	if d > math.Pi {
+		d = 2*math.Pi - d
+	}
+	return d
+}
+
+// isFacing checks if edge is parallel to a street segment and close enough to it
+func (streets *Streets) isFacing(edge geometry.Edge2D, distance float64) bool {
+	if edge.Length() < 0.01 {
+		return false
+	}
+	middle := geometry.Point2D{X: (edge.Start.X + edge.End.X) / 2, Y: (edge.Start.Y + edge.End.Y) / 2}
+	azimuth := edge.Start.Azimuth(edge.End)
+	for _, id := range streets.index.Query(edge.Bounds().Expand(distance)) {
+		s := streets.edges[id]
+		if middle.SegmentDistance(s.Start, s.End) > distance {
+			continue
+		}
+		d := angleDiff(azimuth, s.Start.Azimuth(s.End))
+		if d <= frontageMaxAngle || math.Pi-d <= frontageMaxAngle {
+			return true
+		}
+	}
+	return false
+}
+
+type frontageSide struct {
+	length  float64
+	longest geometry.Edge2D
+}
+
+// frontageSides groups consecutive street facing edges with similar directions
+func frontageSides(edges []geometry.Edge2D, facing []bool) []frontageSide {
+	n := len(edges)
+	start := 0
+	for start < n && facing[start] {
+		start++
+	}
+	if start == n {
+		start = 0
+	}
+	res := make([]frontageSide, 0)
+	var current *frontageSide
+	for k := 0; k < n; k++ {
+		i := (start + k) % n
+		if !facing[i] {
+			current = nil
+			continue
+		}
+		e := edges[i]
+		if current != nil && angleDiff(current.longest.Start.Azimuth(current.longest.End), e.Start.Azimuth(e.End)) > frontageSideAngle {
+			current = nil
+		}
+		if current == nil {
+			res = append(res, frontageSide{})
+			current = &res[len(res)-1]
+		}
+		current.length += e.Length()
+		if e.Length() > current.longest.Length() {
+			current.longest = e
+		}
+	}
+	return res
+}
+
+// DetectFrontage finds street facing edges of a parcel. Edge is street facing if it is
+// almost parallel to a street segment that is closer than distance to the middle of the edge.
+func (streets *Streets) DetectFrontage(multipoly geometry.Multipolygon2D, distance float64) Frontage {
+	res := Frontage{StreetFacing: make([][]bool, len(multipoly.Polygons))}
+	longest := 0.0
+	for p, poly := range multipoly.Polygons {
+		edges := poly.Edges()
+		facing := make([]bool, len(edges))
+		for i, e := range edges {
+			facing[i] = streets.isFacing(e, distance)
+		}
+		res.StreetFacing[p] = facing
+
+		// Outward direction is on the right side for counter clockwise rings
+		outward := math.Pi / 2
+		if poly.Clockwise() {
+			outward = -math.Pi / 2
+		}
+		for _, s := range frontageSides(edges, facing) {
+			res.Sides++
+			res.Length += s.length
+			if s.length > longest {
+				longest = s.length
+				res.Azimuth = math.Mod(s.longest.Start.Azimuth(s.longest.End)+outward+2*math.Pi, 2*math.Pi)
+			}
+		}
+	}
+	return res
+}
+
+// SerializeStreetFacing converts street facing flags to a list of "component:edge" strings
+func SerializeStreetFacing(streetFacing [][]bool) []string {
+	res := make([]string, 0)
+	for p := range streetFacing {
+		for i, f := range streetFacing[p] {
+			if f {
+				res = append(res, strconv.Itoa(p)+":"+strconv.Itoa(i))
+			}
+		}
+	}
+	return res
+}
+
+// ParseStreetFacing restores street facing flags for a multipolygon. Returns nil if
+// edges doesn't match geometry.
+func ParseStreetFacing(multipoly geometry.Multipolygon2D, edges []string) [][]bool {
+	res := make([][]bool, len(multipoly.Polygons))
+	for p, poly := range multipoly.Polygons {
+		res[p] = make([]bool, len(poly.Polygon))
+	}
+	for _, e := range edges {
+		parts := strings.Split(e, ":")
+		if len(parts) != 2 {
+			return nil
+		}
+		p, err := strconv.Atoi(parts[0])
+		if err != nil || p < 0 || p >= len(res) {
+			return nil
+		}
+		i, err := strconv.Atoi(parts[1])
+		if err != nil || i < 0 || i >= len(res[p]) {
+			return nil
+		}
+		res[p][i] = true
+	}
+	return res
+}
+
+// Maximum distance between vertices of source and simplified geometries to consider them same
+const frontageVertexDistance = 0.001
+
+func findVertex(ring geometry.LineString2D, p geometry.Point2D) int {
+	for i, v := range ring {
+		if v.Distance(p) < frontageVertexDistance {
+			return i
+		}
+	}
+	return -1
+}
+
+// MapStreetFacing transfers street facing flags stored for source geometry to its simplified version.
+// Simplified edge is street facing if most of source edges that it replaces are street facing.
+// Returns nil if vertices of simplified geometry can't be found in source one.
+func MapStreetFacing(src geometry.Multipolygon2D, streetFacing [][]bool, dst geometry.Multipolygon2D) [][]bool {
+	res := make([][]bool, len(dst.Polygons))
+	for p, poly := range dst.Polygons {
+		ring := poly.Polygon
+		res[p] = make([]bool, len(ring))
+		for i := range ring {
+			a := ring[i]
+			b := ring[(i+1)%len(ring)]
+			found := false
+			for q, sp := range src.Polygons {
+				ia := findVertex(sp.Polygon, a)
+				ib := findVertex(sp.Polygon, b)
+				if ia < 0 || ib < 0 {
+					continue
+				}
+				// Ring could be reversed by repair, use shortest walk between vertices
+				n := len(sp.Polygon)
+				if (ib-ia+n)%n > (ia-ib+n)%n {
+					ia, ib = ib, ia
+				}
+				facing := 0.0
+				total := 0.0
+				for j := ia; j != ib; j = (j + 1) % n {
+					l := sp.Polygon[j].Distance(sp.Polygon[(j+1)%n])
+					total += l
+					if streetFacing[q][j] {
+						facing += l
+					}
+				}
+				res[p][i] = total > 0 && facing*2 > total
+				found = true
+				break
+			}
+			if !found {
+				return nil
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,62 @@
+package ops
+
+import (
+	"math"
+	"testing"
+
+	"github.com/statecrafthq/borg/geometry"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectFrontageBlocks(t *testing.T) {
+	block := geometry.LineString2D{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 50}, {X: 0, Y: 50}}
+	streets := NewStreets([]geometry.LineString2D{block}, true)
+
+	// Corner lot
+	corner := geometry.Multipolygon2D{Polygons: []geometry.Polygon2D{geometry.NewSimplePolygon([]geometry.Point2D{{X: 0, Y: 0}, {X: 20, Y: 0}, {X: 20, Y: 25}, {X: 0, Y: 25}})}}
+	res := streets.DetectFrontage(corner, 1)
+	assert.Equal(t, []bool{true, false, false, true}, res.StreetFacing[0])
+	assert.Equal(t, 2, res.Sides)
+	assert.InDelta(t, 45, res.Length, 0.000001)
+	assert.InDelta(t, math.Pi*3/2, res.Azimuth, 0.000001)
+
+	// Interior lot facing north street
+	interior := geometry.Multipolygon2D{Polygons: []geometry.Polygon2D{geometry.NewSimplePolygon([]geometry.Point2D{{X: 40, Y: 25}, {X: 50, Y: 25}, {X: 50, Y: 50}, {X: 40, Y: 50}})}}
+	res = streets.DetectFrontage(interior, 1)
+	assert.Equal(t, []bool{false, false, true, false}, res.StreetFacing[0])
+	assert.Equal(t, 1, res.Sides)
+	assert.InDelta(t, 10, res.Length, 0.000001)
+	assert.InDelta(t, 0, res.Azimuth, 0.000001)
+
+	edges := SerializeStreetFacing(res.StreetFacing)
+	assert.Equal(t, []string{"0:2"}, edges)
+	assert.Equal(t, res.StreetFacing, ParseStreetFacing(interior, edges))
+	assert.Nil(t, ParseStreetFacing(interior, []string{"1:2"}))
+}
+
+func TestDetectFrontageCenterlines(t *testing.T) {
+	centerline := geometry.LineString2D{{X: -100, Y: -8}, {X: 200, Y: -8}}
+	streets := NewStreets([]geometry.LineString2D{centerline}, false)
+	lot := geometry.Multipolygon2D{Polygons: []geometry.Polygon2D{geometry.NewSimplePolygon([]geometry.Point2D{{X: 0, Y: 0}, {X: 0, Y: 25}, {X: 20, Y: 25}, {X: 20, Y: 0}})}}
+	res := streets.DetectFrontage(lot, 15)
+	assert.Equal(t, []bool{false, false, false, true}, res.StreetFacing[0])
+	assert.Equal(t, 1, res.Sides)
+	assert.InDelta(t, math.Pi, res.Azimuth, 0.000001)
+}
+
+func TestMapStreetFacing(t *testing.T) {
+	src := geometry.Multipolygon2D{Polygons: []geometry.Polygon2D{geometry.NewSimplePolygon([]geometry.Point2D{{X: 0, Y: 0}, {X: 10, Y: 0.1}, {X: 20, Y: 0}, {X: 20, Y: 10}, {X: 0, Y: 10}})}}
+	streetFacing := [][]bool{{true, true, false, false, false}}
+
+	// Vertex of street facing side is removed by simplification
+	simplified := geometry.Multipolygon2D{Polygons: []geometry.Polygon2D{geometry.NewSimplePolygon([]geometry.Point2D{{X: 0, Y: 0}, {X: 20, Y: 0}, {X: 20, Y: 10}, {X: 0, Y: 10}})}}
+	assert.Equal(t, [][]bool{{true, false, false, false}}, MapStreetFacing(src, streetFacing, simplified))
+
+	// Ring is reversed
+	reversed := geometry.Multipolygon2D{Polygons: []geometry.Polygon2D{geometry.NewSimplePolygon([]geometry.Point2D{{X: 0, Y: 0}, {X: 0, Y: 10}, {X: 20, Y: 10}, {X: 20, Y: 0}})}}
+	assert.Equal(t, [][]bool{{false, false, false, true}}, MapStreetFacing(src, streetFacing, reversed))
+
+	// Unknown vertex
+	moved := geometry.Multipolygon2D{Polygons: []geometry.Polygon2D{geometry.NewSimplePolygon([]geometry.Point2D{{X: 0, Y: 0}, {X: 20, Y: 0}, {X: 20, Y: 11}, {X: 0, Y: 10}})}}
+	assert.Nil(t, MapStreetFacing(src, streetFacing, moved))
+}
@@ -0,0 +1,486 @@
+package ops
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/statecrafthq/borg/utils"
+	"gopkg.in/cheggaaa/pb.v1"
+)
+
+// ImportOptions configures streaming import
+type ImportOptions struct {
+	BatchSize  int
+	BatchBytes int
+	Workers    int
+	Retries    int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	Checkpoint string
+	DeadLetter string
+	Resume     bool
+}
+
+// ImportCheckpoint is a number of records of a source that were acknowledged by server. Size and
+// modification time of the source are used to detect that it was changed since checkpoint.
+type ImportCheckpoint struct {
+	Source    string `json:"source"`
+	Size      int64  `json:"size"`
+	ModTime   int64  `json:"mod_time"`
+	Line      int    `json:"line"`
+	Completed bool   `json:"completed"`
+}
+
+// LoadImportCheckpoint reads checkpoint file, returns nil if file doesn't exist
+func LoadImportCheckpoint(src string) (*ImportCheckpoint, error) {
+	if !utils.FileExists(src) {
+		return nil, nil
+	}
+	data, e := ioutil.ReadFile(src)
+	if e != nil {
+		return nil, e
+	}
+	var res ImportCheckpoint
+	e = json.Unmarshal(data, &res)
+	if e != nil {
+		return nil, e
+	}
+	return &res, nil
+}
+
+// WriteImportCheckpoint replaces checkpoint file
+func WriteImportCheckpoint(dst string, checkpoint ImportCheckpoint) error {
+	data, e := json.Marshal(checkpoint)
+	if e != nil {
+		return e
+	}
+	e = ioutil.WriteFile(dst+".tmp", data, 0644)
+	if e != nil {
+		return e
+	}
+	return os.Rename(dst+".tmp", dst)
+}
+
+type importBatch struct {
+	start   int
+	end     int
+	records []map[string]interface{}
+	sizes   []int
+}
+
+func (batch *importBatch) bytes() int {
+	res := 0
+	for _, s := range batch.sizes {
+		res += s
+	}
+	return res
+}
+
+// Importer sends records of a dataset to server in batches
+type Importer struct {
+	options ImportOptions
+	send    func(records []map[string]interface{}) error
+	retire  func(ids []string) error
+	sleep   func(d time.Duration)
+
+	lock         sync.Mutex
+	source       string
+	sourceInfo   os.FileInfo
+	done         map[int]int
+	acknowledged int
+	deadLetter   *bufio.Writer
+	deadLetters  int
+	failure      error
+	sentRecords  int
+	sentBytes    int
+	retired      int
+}
+
+// NewImporter creates importer that sends batches with send function
+func NewImporter(options ImportOptions, send func(records []map[string]interface{}) error) *Importer {
+	if options.Workers < 1 {
+		options.Workers = 1
+	}
+	if options.BatchSize < 1 {
+		options.BatchSize = 1
+	}
+	return &Importer{options: options, send: send, sleep: time.Sleep}
+}
+
+// SetRetire sets function that retires removed records on server. Without it import fails on
+// tombstone records.
+func (imp *Importer) SetRetire(retire func(ids []string) error) {
+	imp.retire = retire
+}
+
+// DeadLetters returns number of batches written to dead letter file
+func (imp *Importer) DeadLetters() int {
+	return imp.deadLetters
+}
+
+// Sent returns number of records and serialized bytes acknowledged by server
+func (imp *Importer) Sent() (int, int) {
+	imp.lock.Lock()
+	defer imp.lock.Unlock()
+	return imp.sentRecords, imp.sentBytes
+}
+
+// Retired returns number of removed records retired on server
+func (imp *Importer) Retired() int {
+	imp.lock.Lock()
+	defer imp.lock.Unlock()
+	return imp.retired
+}
+
+// stripMetadata removes metadata fields: everything that starts with "$"
+func stripMetadata(row map[string]interface{}) {
+	toRemove := make([]string, 0)
+	for k := range row {
+		if strings.HasPrefix(k, "$") {
+			toRemove = append(toRemove, k)
+		}
+	}
+	for k := range toRemove {
+		delete(row, toRemove[k])
+	}
+}
+
+// sendBatch sends records of a batch and retires removed ones
+func (imp *Importer) sendBatch(batch *importBatch) error {
+	records := make([]map[string]interface{}, 0)
+	ids := make([]string, 0)
+	for _, r := range batch.records {
+		if IsTombstone(r) {
+			ids = append(ids, fmt.Sprintf("%v", r["id"]))
+		} else {
+			records = append(records, r)
+		}
+	}
+	if len(records) > 0 {
+		e := imp.send(records)
+		if e != nil {
+			return e
+		}
+	}
+	if len(ids) > 0 {
+		return imp.retire(ids)
+	}
+	return nil
+}
+
+// sendWithRetry sends batch retrying with exponential backoff
+func (imp *Importer) sendWithRetry(batch *importBatch) error {
+	backoff := imp.options.Backoff
+	for attempt := 0; ; attempt++ {
+		e := imp.sendBatch(batch)
+		if e == nil {
+			return nil
+		}
+		if e == utils.ErrPayloadTooLarge || attempt >= imp.options.Retries {
+			return e
+		}
+		fmt.Printf("Batch %d-%d failed (attempt %d of %d): %v. Retrying in %s\n", batch.start, batch.end, attempt+1, imp.options.Retries+1, e, backoff)
+		imp.sleep(backoff)
+		backoff *= 2
+		if imp.options.MaxBackoff > 0 && backoff > imp.options.MaxBackoff {
+			backoff = imp.options.MaxBackoff
+		}
+	}
+}
+
+func (imp *Importer) failed() bool {
+	imp.lock.Lock()
+	defer imp.lock.Unlock()
+	return imp.failure != nil
+}
+
+// process sends batch splitting it in halves when server rejects it as too large. Parts that failed
+// are written to dead letter file if it is configured.
+func (imp *Importer) process(batch *importBatch) error {
+	e := imp.sendWithRetry(batch)
+	if e == utils.ErrPayloadTooLarge && len(batch.records) > 1 {
+		mid := len(batch.records) / 2
+		fmt.Printf("Batch %d-%d is too large (%d bytes), splitting\n", batch.start, batch.end, batch.bytes())
+		e = imp.process(&importBatch{start: batch.start, end: batch.start + mid, records: batch.records[:mid], sizes: batch.sizes[:mid]})
+		if e != nil {
+			return e
+		}
+		return imp.process(&importBatch{start: batch.start + mid, end: batch.end, records: batch.records[mid:], sizes: batch.sizes[mid:]})
+	}
+	imp.lock.Lock()
+	defer imp.lock.Unlock()
+	if e == nil {
+		for _, r := range batch.records {
+			if IsTombstone(r) {
+				imp.retired++
+			}
+		}
+		imp.sentRecords += len(batch.records)
+		imp.sentBytes += batch.bytes()
+		return nil
+	}
+	if imp.deadLetter == nil {
+		return e
+	}
+	fmt.Printf("Batch %d-%d moved to dead letter file: %v\n", batch.start, batch.end, e)
+	for _, r := range batch.records {
+		bytes, e := json.Marshal(r)
+		if e != nil {
+			return e
+		}
+		_, e = imp.deadLetter.Write(bytes)
+		if e != nil {
+			return e
+		}
+		_, e = imp.deadLetter.WriteString("\n")
+		if e != nil {
+			return e
+		}
+	}
+	imp.deadLetters++
+	return nil
+}
+
+// complete marks batch as acknowledged and moves checkpoint over all contiguous completed batches
+func (imp *Importer) complete(batch *importBatch, sendError error) {
+	imp.lock.Lock()
+	defer imp.lock.Unlock()
+	if sendError != nil {
+		if imp.failure == nil {
+			imp.failure = sendError
+		}
+		return
+	}
+	imp.done[batch.start] = batch.end
+	moved := false
+	for {
+		end, ok := imp.done[imp.acknowledged]
+		if !ok {
+			break
+		}
+		delete(imp.done, imp.acknowledged)
+		imp.acknowledged = end
+		moved = true
+	}
+	if moved && imp.options.Checkpoint != "" {
+		if imp.deadLetter != nil {
+			// Dead letters should be persisted before checkpoint moves over them
+			e := imp.deadLetter.Flush()
+			if e != nil && imp.failure == nil {
+				imp.failure = e
+			}
+		}
+		e := WriteImportCheckpoint(imp.options.Checkpoint, imp.checkpoint(false))
+		if e != nil && imp.failure == nil {
+			imp.failure = e
+		}
+	}
+}
+
+func (imp *Importer) checkpoint(completed bool) ImportCheckpoint {
+	return ImportCheckpoint{
+		Source:    imp.source,
+		Size:      imp.sourceInfo.Size(),
+		ModTime:   imp.sourceInfo.ModTime().UnixNano(),
+		Line:      imp.acknowledged,
+		Completed: completed,
+	}
+}
+
+// Run imports all records of a source file
+func (imp *Importer) Run(src string) error {
+
+	//
+	// Resuming
+	//
+
+	info, e := os.Stat(src)
+	if e != nil {
+		return e
+	}
+	start := 0
+	if imp.options.Resume {
+		if imp.options.Checkpoint == "" {
+			return errors.New("Checkpoint file is required for resuming")
+		}
+		checkpoint, e := LoadImportCheckpoint(imp.options.Checkpoint)
+		if e != nil {
+			return e
+		}
+		if checkpoint != nil && checkpoint.Completed {
+			fmt.Println("Previous import was completed, starting from the beginning")
+		} else if checkpoint != nil {
+			if checkpoint.Source != src {
+				return errors.New("Checkpoint was created for " + checkpoint.Source)
+			}
+			if checkpoint.Size != info.Size() || checkpoint.ModTime != info.ModTime().UnixNano() {
+				return errors.New("Source " + src + " was changed since checkpoint")
+			}
+			start = checkpoint.Line
+			fmt.Printf("Resuming from record %d\n", start)
+		}
+	}
+	imp.source = src
+	imp.sourceInfo = info
+	imp.done = make(map[int]int)
+	imp.acknowledged = start
+	imp.failure = nil
+	imp.deadLetters = 0
+	imp.sentRecords = 0
+	imp.sentBytes = 0
+	imp.retired = 0
+	started := time.Now()
+
+	// Opening files
+	file, e := os.Open(src)
+	if e != nil {
+		return e
+	}
+	defer file.Close()
+	lines, e := utils.CountLines(file)
+	if e != nil {
+		return e
+	}
+	if imp.options.DeadLetter != "" {
+		flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if imp.options.Resume {
+			flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		}
+		deadLetterFile, e := os.OpenFile(imp.options.DeadLetter, flags, 0644)
+		if e != nil {
+			return e
+		}
+		defer deadLetterFile.Close()
+		imp.deadLetter = bufio.NewWriter(deadLetterFile)
+	}
+
+	//
+	// Workers
+	//
+
+	bar := pb.StartNew(lines)
+	bar.Set(start)
+	batches := make(chan *importBatch, imp.options.Workers)
+	var wg sync.WaitGroup
+	for i := 0; i < imp.options.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				if imp.failed() {
+					continue
+				}
+				e := imp.process(batch)
+				imp.complete(batch, e)
+				bar.Add(len(batch.records))
+			}
+		}()
+	}
+
+	//
+	// Reading
+	//
+
+	rd := bufio.NewReader(file)
+	index := 0
+	pending := &importBatch{start: start, records: make([]map[string]interface{}, 0)}
+	var readError error
+	for !imp.failed() {
+		line, e := rd.ReadBytes('\n')
+		if e != nil && e != io.EOF {
+			readError = e
+			break
+		}
+		if len(line) > 0 {
+			if index >= start {
+				var d map[string]interface{}
+				e := json.Unmarshal(line, &d)
+				if e != nil {
+					readError = e
+					break
+				}
+				// Tombstones keep removal mark to be routed to retire mutation
+				if IsTombstone(d) {
+					if imp.retire == nil {
+						readError = fmt.Errorf("Record %v is removed, but retire mutation is not configured", d["id"])
+						break
+					}
+				} else {
+					stripMetadata(d)
+				}
+				serialized, e := json.Marshal(d)
+				if e != nil {
+					readError = e
+					break
+				}
+
+				// Flush batch if record doesn't fit
+				size := len(serialized)
+				if imp.options.BatchBytes > 0 && len(pending.records) > 0 && pending.bytes()+size > imp.options.BatchBytes {
+					pending.end = index
+					batches <- pending
+					pending = &importBatch{start: index, records: make([]map[string]interface{}, 0)}
+				}
+				pending.records = append(pending.records, d)
+				pending.sizes = append(pending.sizes, size)
+				if len(pending.records) >= imp.options.BatchSize {
+					pending.end = index + 1
+					batches <- pending
+					pending = &importBatch{start: index + 1, records: make([]map[string]interface{}, 0)}
+				}
+			}
+			index++
+		}
+		if e == io.EOF {
+			break
+		}
+	}
+	if readError == nil && len(pending.records) > 0 && !imp.failed() {
+		pending.end = index
+		batches <- pending
+	}
+	close(batches)
+	wg.Wait()
+
+	if imp.deadLetter != nil {
+		e = imp.deadLetter.Flush()
+		if e != nil {
+			return e
+		}
+	}
+	if readError != nil {
+		return readError
+	}
+	if imp.failure != nil {
+		return imp.failure
+	}
+	bar.FinishPrint("Importing completed")
+
+	// Completed import is never resumed
+	if imp.options.Checkpoint != "" {
+		e = WriteImportCheckpoint(imp.options.Checkpoint, imp.checkpoint(true))
+		if e != nil {
+			return e
+		}
+	}
+
+	// Throughput
+	elapsed := time.Since(started).Seconds()
+	if elapsed > 0 {
+		mb := float64(imp.sentBytes) / (1024 * 1024)
+		fmt.Printf("-- Records: %d, %.1f records/sec\n", imp.sentRecords, float64(imp.sentRecords)/elapsed)
+		fmt.Printf("-- Data: %.2f MB, %.2f MB/sec\n", mb, mb/elapsed)
+	}
+	if imp.retired > 0 {
+		fmt.Printf("-- Retired: %d\n", imp.retired)
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package ops
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/statecrafthq/borg/utils"
+)
+
+func writeImportSource(t *testing.T, dir string, count int) string {
+	lines := make([]string, 0)
+	for i := 0; i < count; i++ {
+		lines = append(lines, fmt.Sprintf(`{"id":"%d","$geometry_src":[]}`, i))
+	}
+	src := filepath.Join(dir, "source.ols")
+	e := ioutil.WriteFile(src, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+	return src
+}
+
+func TestImporterRetries(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "importer")
+	defer os.RemoveAll(dir)
+	src := writeImportSource(t, dir, 10)
+
+	var lock sync.Mutex
+	attempts := make(map[string]int)
+	imported := make(map[string]bool)
+	importer := NewImporter(ImportOptions{BatchSize: 3, Workers: 3, Retries: 2, Backoff: time.Second, Checkpoint: filepath.Join(dir, "checkpoint"), DeadLetter: filepath.Join(dir, "dead.ols")}, func(records []map[string]interface{}) error {
+		lock.Lock()
+		defer lock.Unlock()
+		first := records[0]["id"].(string)
+		attempts[first]++
+		if _, ok := records[0]["$geometry_src"]; ok {
+			return errors.New("Metadata should be removed")
+		}
+		// Batch starting from 3 always fails, batch starting from 6 fails once
+		if first == "3" || (first == "6" && attempts[first] == 1) {
+			return errors.New("Server error")
+		}
+		for _, r := range records {
+			imported[r["id"].(string)] = true
+		}
+		return nil
+	})
+	sleeps := make([]time.Duration, 0)
+	importer.sleep = func(d time.Duration) {
+		lock.Lock()
+		defer lock.Unlock()
+		sleeps = append(sleeps, d)
+	}
+	e := importer.Run(src)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if attempts["3"] != 3 || attempts["6"] != 2 {
+		t.Errorf("Unexpected attempts %v", attempts)
+	}
+	if len(imported) != 7 || importer.DeadLetters() != 1 {
+		t.Errorf("Unexpected imported records %v", imported)
+	}
+	total := time.Duration(0)
+	for _, s := range sleeps {
+		total += s
+	}
+	if total != 4*time.Second {
+		t.Errorf("Unexpected backoff %v", sleeps)
+	}
+	dead, _ := ioutil.ReadFile(filepath.Join(dir, "dead.ols"))
+	if strings.Count(string(dead), "\n") != 3 {
+		t.Errorf("Unexpected dead letters %s", string(dead))
+	}
+	checkpoint, e := LoadImportCheckpoint(filepath.Join(dir, "checkpoint"))
+	if e != nil || checkpoint == nil || checkpoint.Line != 10 || checkpoint.Source != src {
+		t.Errorf("Unexpected checkpoint %v", checkpoint)
+	}
+}
+
+func TestImporterResume(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "importer")
+	defer os.RemoveAll(dir)
+	src := writeImportSource(t, dir, 10)
+	options := ImportOptions{BatchSize: 2, Workers: 1, Checkpoint: filepath.Join(dir, "checkpoint")}
+
+	// Fails on a third batch without retries
+	sent := 0
+	e := NewImporter(options, func(records []map[string]interface{}) error {
+		if records[0]["id"] == "4" {
+			return errors.New("Server error")
+		}
+		sent += len(records)
+		return nil
+	}).Run(src)
+	if e == nil {
+		t.Fatal("Expected import to fail")
+	}
+	checkpoint, _ := LoadImportCheckpoint(options.Checkpoint)
+	if checkpoint == nil || checkpoint.Line != 4 {
+		t.Fatalf("Unexpected checkpoint %v", checkpoint)
+	}
+
+	// Changed source is not resumed
+	options.Resume = true
+	info, _ := os.Stat(src)
+	os.Chtimes(src, time.Now(), info.ModTime().Add(time.Second))
+	e = NewImporter(options, func(records []map[string]interface{}) error {
+		return nil
+	}).Run(src)
+	if e == nil {
+		t.Fatal("Expected changed source to be rejected")
+	}
+	os.Chtimes(src, time.Now(), info.ModTime())
+
+	// Resume continues from checkpoint
+	first := ""
+	e = NewImporter(options, func(records []map[string]interface{}) error {
+		if first == "" {
+			first = records[0]["id"].(string)
+		}
+		sent += len(records)
+		return nil
+	}).Run(src)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if first != "4" || sent != 10 {
+		t.Errorf("Expected resume from record 4, got %s and %d sent records", first, sent)
+	}
+
+	// Completed import is started from the beginning
+	checkpoint, _ = LoadImportCheckpoint(options.Checkpoint)
+	if checkpoint == nil || !checkpoint.Completed || checkpoint.Line != 10 {
+		t.Fatalf("Unexpected checkpoint %v", checkpoint)
+	}
+	sent = 0
+	e = NewImporter(options, func(records []map[string]interface{}) error {
+		sent += len(records)
+		return nil
+	}).Run(src)
+	if e != nil || sent != 10 {
+		t.Errorf("Expected full import, got %d sent records", sent)
+	}
+}
+
+func TestImporterBatchBytes(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "importer")
+	defer os.RemoveAll(dir)
+	src := writeImportSource(t, dir, 10)
+
+	// Every record is serialized as {"id":"N"} of 10 bytes
+	batches := make([]int, 0)
+	importer := NewImporter(ImportOptions{BatchSize: 100, BatchBytes: 35}, func(records []map[string]interface{}) error {
+		batches = append(batches, len(records))
+		return nil
+	})
+	e := importer.Run(src)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if len(batches) != 4 || batches[0] != 3 || batches[3] != 1 {
+		t.Errorf("Unexpected batches %v", batches)
+	}
+	records, bytes := importer.Sent()
+	if records != 10 || bytes != 100 {
+		t.Errorf("Unexpected throughput %d records, %d bytes", records, bytes)
+	}
+}
+
+func TestImporterSplitTooLarge(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "importer")
+	defer os.RemoveAll(dir)
+	src := writeImportSource(t, dir, 10)
+
+	// Server accepts at most 3 records, record 7 is always rejected
+	sent := make([]int, 0)
+	importer := NewImporter(ImportOptions{BatchSize: 10, DeadLetter: filepath.Join(dir, "dead.ols")}, func(records []map[string]interface{}) error {
+		if len(records) > 3 {
+			return utils.ErrPayloadTooLarge
+		}
+		for _, r := range records {
+			if r["id"] == "7" {
+				return utils.ErrPayloadTooLarge
+			}
+		}
+		sent = append(sent, len(records))
+		return nil
+	})
+	e := importer.Run(src)
+	if e != nil {
+		t.Fatal(e)
+	}
+	records, _ := importer.Sent()
+	if records != 9 || importer.DeadLetters() != 1 {
+		t.Errorf("Unexpected result: %d records sent in %v, %d dead letters", records, sent, importer.DeadLetters())
+	}
+	dead, _ := ioutil.ReadFile(filepath.Join(dir, "dead.ols"))
+	if string(dead) != "{\"id\":\"7\"}\n" {
+		t.Errorf("Unexpected dead letters %s", string(dead))
+	}
+}
+
+func TestImporterRetire(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "importer")
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "diff.ols")
+	ioutil.WriteFile(src, []byte(`{"id":"1","$geometry_src":[]}
+{"id":"2","$removed":true}
+{"id":"3"}
+{"id":"4","$removed":true}
+`), 0644)
+
+	imported := make([]string, 0)
+	retired := make([]string, 0)
+	importer := NewImporter(ImportOptions{BatchSize: 10}, func(records []map[string]interface{}) error {
+		for _, r := range records {
+			imported = append(imported, r["id"].(string))
+		}
+		return nil
+	})
+
+	// Removed records are not imported without retire mutation
+	e := importer.Run(src)
+	if e == nil {
+		t.Error("Import should fail without retire mutation")
+	}
+
+	imported = imported[:0]
+	importer.SetRetire(func(ids []string) error {
+		retired = append(retired, ids...)
+		return nil
+	})
+	e = importer.Run(src)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if strings.Join(imported, ",") != "1,3" || strings.Join(retired, ",") != "2,4" || importer.Retired() != 2 {
+		t.Errorf("Unexpected imported %v and retired %v records", imported, retired)
+	}
+}
+
+func TestTombstone(t *testing.T) {
+	tombstone := NewTombstone(map[string]interface{}{"id": "1", "displayId": []interface{}{"A"}, "geometry": []interface{}{}})
+	if !IsTombstone(tombstone) || tombstone["id"] != "1" || tombstone["geometry"] != nil || tombstone["displayId"] == nil {
+		t.Errorf("Unexpected tombstone %v", tombstone)
+	}
+	if IsTombstone(map[string]interface{}{"id": "1"}) || IsTombstone(map[string]interface{}{"id": "1", FieldRemoved: false}) {
+		t.Error("Record without removal mark is not a tombstone")
+	}
+}
@@ -9,11 +9,14 @@ import (
 
 type Layout struct {
 	Analyzed    bool
+	Component   int
 	Fits        bool
 	HasLocation bool
 	Center      geometry.Point2D
 	Footprint   geometry.Polygon2D
 	Angle       float64
+	HasEnvelope bool
+	Envelope    geometry.Polygon2D
 }
 
 // intersectPoints = (poly, origin, alpha) ->
@@ -92,7 +95,21 @@ func loadEdgedCenters(poly geometry.Polygon2D, smallSide float64, largeSide floa
 			}
 
 			// If all of them are in our poly
-			if poly.ContainsPoint(startCenter) && poly.ContainsPoint(endCenter) && poly.ContainsPoint(startTop) && poly.ContainsPoint(endTop) {
+			fits := poly.ContainsPoint(startCenter) && poly.ContainsPoint(endCenter) && poly.ContainsPoint(startTop) && poly.ContainsPoint(endTop)
+
+			// Points are not enough when polygon has holes: check whole rectangle (slightly shrinked to ignore touching)
+			if fits && len(poly.Holes) > 0 {
+				corners := make([]geometry.Point2D, 0)
+				for _, s := range [][]float64{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}} {
+					corners = append(corners, middle.Shift(geometry.Point2D{
+						X: id.DX*s[0]*largeSide*0.49 + offsetFromEdge.DX*s[1]*0.49,
+						Y: id.DY*s[0]*largeSide*0.49 + offsetFromEdge.DY*s[1]*0.49,
+					}))
+				}
+				fits = poly.ContainsWithHoles(geometry.NewSimplePolygon(corners))
+			}
+
+			if fits {
 				if enable_logs {
 					fmt.Println("ok")
 				}
@@ -178,16 +195,32 @@ func loadEdgedCenters(poly geometry.Polygon2D, smallSide float64, largeSide floa
 // 	return Layout{Analyzed: true, Fits: false}
 // }
 
+func gridCenters(poly geometry.Polygon2D, size int) []geometry.Point2D {
+	res := make([]geometry.Point2D, 0)
+	bounds := poly.Bounds()
+	for i := 1; i <= size; i++ {
+		for j := 1; j <= size; j++ {
+			p := geometry.Point2D{
+				X: bounds.MinX + (bounds.MaxX-bounds.MinX)*float64(i)/float64(size+1),
+				Y: bounds.MinY + (bounds.MaxY-bounds.MinY)*float64(j)/float64(size+1),
+			}
+			if poly.ContainsPoint(p) {
+				res = append(res, p)
+			}
+		}
+	}
+	return res
+}
+
 func LayoutRectangle(poly geometry.Polygon2D, width float64, height float64) Layout {
+	return layoutRectangle(poly, width, height, true)
+}
+
+func layoutRectangle(poly geometry.Polygon2D, width float64, height float64, anyRotation bool) Layout {
 	t := poly.Classify()
 	smallSide := math.Min(width, height)
 	largeSide := math.Max(width, height)
 
-	// Ignore all complex polygons
-	if t == geometry.TypePolygonWithHoles {
-		return Layout{Analyzed: false}
-	}
-
 	// Fast-handling of rectangles
 	if t == geometry.TypeRectangle {
 		sides := poly.Edges()
@@ -218,12 +251,16 @@ func LayoutRectangle(poly geometry.Polygon2D, width float64, height float64) Lay
 
 	// Rectangle
 	centers := []geometry.Point2D{poly.Center()}
+	// Center of polygon with holes is often inside of a courtyard
+	if t == geometry.TypePolygonWithHoles {
+		centers = append(centers, gridCenters(poly, 4)...)
+	}
 	sideAngles := poly.Azimuths()
 	footprint := geometry.NewSimplePolygon([]geometry.Point2D{
-		{-smallSide / 2, largeSide / 2},
-		{smallSide / 2, largeSide / 2},
-		{smallSide / 2, -largeSide / 2},
-		{-smallSide / 2, -largeSide / 2},
+		{X: -smallSide / 2, Y: largeSide / 2},
+		{X: smallSide / 2, Y: largeSide / 2},
+		{X: smallSide / 2, Y: -largeSide / 2},
+		{X: -smallSide / 2, Y: -largeSide / 2},
 	})
 
 	// fmt.Println("Centers")
@@ -263,7 +300,7 @@ func LayoutRectangle(poly geometry.Polygon2D, width float64, height float64) Lay
 				Rotate(sideAngles[i]).
 				Shift(center)
 
-			if poly.Contains(r) {
+			if poly.ContainsWithHoles(r) {
 				return Layout{
 					Analyzed: true, Fits: true, HasLocation: true,
 					Center: center,
@@ -275,13 +312,17 @@ func LayoutRectangle(poly geometry.Polygon2D, width float64, height float64) Lay
 		// Full Search
 		//
 
+		if !anyRotation {
+			continue
+		}
+
 		rotationIterations := 1000
 		for i := 0; i < rotationIterations; i++ {
 			alpha := float64(i) * math.Pi * 2 / float64(rotationIterations)
 			r := footprint.
 				Rotate(alpha).
 				Shift(center)
-			if poly.Contains(r) {
+			if poly.ContainsWithHoles(r) {
 				return Layout{
 					Analyzed: true, Fits: true, HasLocation: true,
 					Center: center,
@@ -15,10 +15,10 @@ import (
 
 func TestLayout(t *testing.T) {
 	poly := geometry.NewProjectedSimplePolygon([]geometry.PointGeo{
-		{-73.998824, 40.716576},
-		{-73.998862, 40.716515},
-		{-73.998523, 40.716396},
-		{-73.998485, 40.716457},
+		{Longitude: -73.998824, Latitude: 40.716576},
+		{Longitude: -73.998862, Latitude: 40.716515},
+		{Longitude: -73.998523, Latitude: 40.716396},
+		{Longitude: -73.998485, Latitude: 40.716457},
 	})
 	layout := LayoutRectangle(poly, 3.6576, 10.668)
 	assert.True(t, layout.Analyzed)
@@ -29,11 +29,11 @@ func TestLayout(t *testing.T) {
 
 func TestConvex(t *testing.T) {
 	poly := geometry.NewProjectedSimplePolygon([]geometry.PointGeo{
-		{-73.999994, 40.72815},
-		{-73.999787, 40.728396},
-		{-74.000423, 40.728709},
-		{-74.000626, 40.728467},
-		{-74.000314, 40.728308},
+		{Longitude: -73.999994, Latitude: 40.72815},
+		{Longitude: -73.999787, Latitude: 40.728396},
+		{Longitude: -74.000423, Latitude: 40.728709},
+		{Longitude: -74.000626, Latitude: 40.728467},
+		{Longitude: -74.000314, Latitude: 40.728308},
 	})
 	layout := LayoutRectangle(poly, 3.6576, 10.668)
 	assert.True(t, layout.Analyzed)
@@ -43,11 +43,11 @@ func TestConvex(t *testing.T) {
 
 func TestNarrow(t *testing.T) {
 	poly := geometry.NewProjectedSimplePolygon([]geometry.PointGeo{
-		{-74.008608, 40.717205},
-		{-74.008557, 40.71727},
-		{-74.008904, 40.717317},
-		{-74.008917, 40.717247},
-		{-74.008763, 40.717226},
+		{Longitude: -74.008608, Latitude: 40.717205},
+		{Longitude: -74.008557, Latitude: 40.71727},
+		{Longitude: -74.008904, Latitude: 40.717317},
+		{Longitude: -74.008917, Latitude: 40.717247},
+		{Longitude: -74.008763, Latitude: 40.717226},
 	})
 	layout := LayoutRectangle(poly, 3.6576, 10.668)
 	assert.True(t, layout.Analyzed)
@@ -57,11 +57,11 @@ func TestNarrow(t *testing.T) {
 
 func TestComplex(t *testing.T) {
 	poly := geometry.NewProjectedSimplePolygon([]geometry.PointGeo{
-		{-74.002324, 40.719039},
-		{-74.002587, 40.719159},
-		{-74.00265, 40.719208},
-		{-74.002773, 40.719072},
-		{-74.00243, 40.718915},
+		{Longitude: -74.002324, Latitude: 40.719039},
+		{Longitude: -74.002587, Latitude: 40.719159},
+		{Longitude: -74.00265, Latitude: 40.719208},
+		{Longitude: -74.002773, Latitude: 40.719072},
+		{Longitude: -74.00243, Latitude: 40.718915},
 	})
 	layout := LayoutRectangle(poly, 3.6576, 10.668)
 	assert.True(t, layout.Analyzed)
@@ -161,13 +161,13 @@ func TestUrbynNegative(t *testing.T) {
 
 	footprint := geometry.NewSimplePolygon(
 		[]geometry.Point2D{
-			{-3.6576 / 2, 10.668 / 2},
-			{3.6576 / 2, 10.668 / 2},
-			{3.6576 / 2, -10.668 / 2},
-			{-3.6576 / 2, -10.668 / 2},
+			{X: -3.6576 / 2, Y: 10.668 / 2},
+			{X: 3.6576 / 2, Y: 10.668 / 2},
+			{X: 3.6576 / 2, Y: -10.668 / 2},
+			{X: -3.6576 / 2, Y: -10.668 / 2},
 		}).
 		Rotate(4.052654523130833).
-		Shift(geometry.Point2D{-0.277551, 16.671435})
+		Shift(geometry.Point2D{X: -0.277551, Y: 16.671435})
 
 	fmt.Println(parcel.Contains(footprint))
 
@@ -260,3 +260,41 @@ func TestCornerCases(t *testing.T) {
 	// Narrow triangle
 	// testLayoutCase(t, "1-01065-0132", "[[[[-73.98691,40.767126],[-73.986612,40.766955],[-73.986586,40.76699],[-73.98691,40.767126]]]]")
 }
+
+func TestLayoutWithHoles(t *testing.T) {
+	courtyard := geometry.Polygon2D{
+		Polygon: []geometry.Point2D{{X: 0, Y: 0}, {X: 30, Y: 0}, {X: 30, Y: 30}, {X: 0, Y: 30}},
+		Holes:   [][]geometry.Point2D{{{X: 8, Y: 8}, {X: 22, Y: 8}, {X: 22, Y: 22}, {X: 8, Y: 22}}},
+	}
+	layout := LayoutRectangle(courtyard, 3.6576, 10.668)
+	assert.True(t, layout.Analyzed)
+	assert.True(t, layout.Fits)
+	footprint := geometry.NewSimplePolygon([]geometry.Point2D{
+		{X: -3.6576 / 2, Y: 10.668 / 2},
+		{X: 3.6576 / 2, Y: 10.668 / 2},
+		{X: 3.6576 / 2, Y: -10.668 / 2},
+		{X: -3.6576 / 2, Y: -10.668 / 2},
+	}).Rotate(layout.Angle).Shift(layout.Center)
+	assert.False(t, geometry.NewSimplePolygon(courtyard.Holes[0]).Intersects(footprint))
+
+	// Ring is too narrow for this one
+	layout = LayoutRectangle(courtyard, 10, 12)
+	assert.True(t, layout.Analyzed)
+	assert.False(t, layout.Fits)
+}
+
+func TestLayoutMultipolygon(t *testing.T) {
+	multi := geometry.Multipolygon2D{Polygons: []geometry.Polygon2D{
+		geometry.NewSimplePolygon([]geometry.Point2D{{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 2}, {X: 0, Y: 2}}),
+		geometry.NewSimplePolygon([]geometry.Point2D{{X: 10, Y: 0}, {X: 16, Y: 0}, {X: 16, Y: 20}, {X: 10, Y: 20}}),
+	}}
+	layout := LayoutFootprintMulti(multi, DefaultFootprints()[0], nil)
+	assert.True(t, layout.Analyzed)
+	assert.True(t, layout.Fits)
+	assert.Equal(t, 1, layout.Component)
+
+	multi.Polygons = multi.Polygons[:1]
+	layout = LayoutFootprintMulti(multi, DefaultFootprints()[0], nil)
+	assert.True(t, layout.Analyzed)
+	assert.False(t, layout.Fits)
+}
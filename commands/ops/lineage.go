@@ -0,0 +1,231 @@
+package ops
+
+import (
+	"bufio"
+	"encoding/json"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/statecrafthq/borg/geometry"
+	"github.com/statecrafthq/borg/utils"
+)
+
+// Types of lineage events
+const (
+	LineageSplit    = "split"
+	LineageMerge    = "merge"
+	LineageReplace  = "replace"
+	LineageReparcel = "reparcel"
+)
+
+// LineageEvent links records that disappeared with records that replaced them
+type LineageEvent struct {
+	Type         string   `json:"type"`
+	Date         string   `json:"date,omitempty"`
+	Predecessors []string `json:"predecessors"`
+	Successors   []string `json:"successors"`
+}
+
+// Lineage is a result of lineage detection
+type Lineage struct {
+	Predecessors map[string][]string
+	Successors   map[string][]string
+	Events       []LineageEvent
+}
+
+// LineageIndexCell is a cell size of spatial index used for matching records (in meters)
+const LineageIndexCell = 100
+
+// LineageTracker collects records that disappeared and appeared between two versions
+type LineageTracker struct {
+	removed map[string]geometry.MultipolygonGeo
+	added   map[string]geometry.MultipolygonGeo
+}
+
+// NewLineageTracker creates empty tracker
+func NewLineageTracker() *LineageTracker {
+	return &LineageTracker{removed: make(map[string]geometry.MultipolygonGeo), added: make(map[string]geometry.MultipolygonGeo)}
+}
+
+func rowGeometry(row map[string]interface{}) (bool, geometry.MultipolygonGeo) {
+	g, ok := row["geometry"]
+	if !ok {
+		return false, geometry.MultipolygonGeo{}
+	}
+	return true, geometry.NewGeoMultipolygon(utils.ParseFloat4(g.([]interface{})))
+}
+
+// Removed records a record that is missing in latest version
+func (t *LineageTracker) Removed(row map[string]interface{}) {
+	if ok, g := rowGeometry(row); ok {
+		t.removed[recordID(row)] = g
+	}
+}
+
+// Added records a record that is missing in previous version
+func (t *LineageTracker) Added(row map[string]interface{}) {
+	if ok, g := rowGeometry(row); ok {
+		t.added[recordID(row)] = g
+	}
+}
+
+func sortedKeys(src map[string]geometry.MultipolygonGeo) []string {
+	res := make([]string, 0, len(src))
+	for k := range src {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Detect matches removed records to added ones when overlap of the smaller shape is at least minOverlap
+func (t *LineageTracker) Detect(minOverlap float64, date string) *Lineage {
+	res := &Lineage{Predecessors: make(map[string][]string), Successors: make(map[string][]string), Events: []LineageEvent{}}
+	if len(t.removed) == 0 || len(t.added) == 0 {
+		return res
+	}
+
+	// Projecting all shapes
+	ids := append(sortedKeys(t.removed), sortedKeys(t.added)...)
+	removedCount := len(t.removed)
+	geo := make([]geometry.MultipolygonGeo, len(ids))
+	bounds := geometry.BoundsGeo{MinLatitude: math.MaxFloat64, MinLongitude: math.MaxFloat64, MaxLatitude: -math.MaxFloat64, MaxLongitude: -math.MaxFloat64}
+	for i, id := range ids {
+		if i < removedCount {
+			geo[i] = t.removed[id]
+		} else {
+			geo[i] = t.added[id]
+		}
+		b := geo[i].Bounds()
+		bounds.MinLatitude = math.Min(bounds.MinLatitude, b.MinLatitude)
+		bounds.MinLongitude = math.Min(bounds.MinLongitude, b.MinLongitude)
+		bounds.MaxLatitude = math.Max(bounds.MaxLatitude, b.MaxLatitude)
+		bounds.MaxLongitude = math.Max(bounds.MaxLongitude, b.MaxLongitude)
+	}
+	proj := geometry.PickProjection(bounds, geometry.MaxProjectionDistortion)
+	shapes := make([]geometry.Multipolygon2D, len(ids))
+	for i := range geo {
+		shapes[i] = geo[i].Project(proj)
+	}
+
+	// Matching added shapes to removed ones
+	index := geometry.NewGridIndex(LineageIndexCell)
+	for i := 0; i < removedCount; i++ {
+		index.Insert(shapes[i].Bounds())
+	}
+	adjacency := make([][]Neighbor, len(ids))
+	for j := removedCount; j < len(ids); j++ {
+		for _, i := range index.Query(shapes[j].Bounds()) {
+			if shapes[i].Overlap(shapes[j]) >= minOverlap {
+				adjacency[i] = append(adjacency[i], Neighbor{Index: j})
+				adjacency[j] = append(adjacency[j], Neighbor{Index: i})
+			}
+		}
+	}
+
+	// Grouping into events
+	components := ConnectedComponents(adjacency, func(a int, b int) bool { return true })
+	groups := make(map[int]*LineageEvent)
+	order := make([]int, 0)
+	for i, c := range components {
+		if len(adjacency[i]) == 0 {
+			continue
+		}
+		event, ok := groups[c]
+		if !ok {
+			event = &LineageEvent{Date: date, Predecessors: []string{}, Successors: []string{}}
+			groups[c] = event
+			order = append(order, c)
+		}
+		if i < removedCount {
+			event.Predecessors = append(event.Predecessors, ids[i])
+		} else {
+			event.Successors = append(event.Successors, ids[i])
+		}
+	}
+	for _, c := range order {
+		event := groups[c]
+		switch {
+		case len(event.Predecessors) == 1 && len(event.Successors) == 1:
+			event.Type = LineageReplace
+		case len(event.Predecessors) == 1:
+			event.Type = LineageSplit
+		case len(event.Successors) == 1:
+			event.Type = LineageMerge
+		default:
+			event.Type = LineageReparcel
+		}
+		for _, p := range event.Predecessors {
+			res.Successors[p] = event.Successors
+		}
+		for _, s := range event.Successors {
+			res.Predecessors[s] = event.Predecessors
+		}
+		res.Events = append(res.Events, *event)
+	}
+	return res
+}
+
+// Apply writes predecessors and successors of a record to extras, keeping already known ones
+func (l *Lineage) Apply(row map[string]interface{}) (map[string]interface{}, error) {
+	id := recordID(row)
+	predecessors, hasPredecessors := l.Predecessors[id]
+	successors, hasSuccessors := l.Successors[id]
+	if !hasPredecessors && !hasSuccessors {
+		return row, nil
+	}
+	extras, e := LoadExtras(row["extras"])
+	if e != nil {
+		return nil, e
+	}
+	if hasPredecessors {
+		_, existing := extras.GetEnum("predecessors")
+		extras.AppendEnum("predecessors", unionStrings(existing, predecessors))
+	}
+	if hasSuccessors {
+		_, existing := extras.GetEnum("successors")
+		extras.AppendEnum("successors", unionStrings(existing, successors))
+	}
+	row["extras"] = extras
+	return row, nil
+}
+
+func unionStrings(a []string, b []string) []string {
+	res := make([]string, 0)
+	added := make(map[string]bool)
+	for _, values := range [][]string{a, b} {
+		for _, v := range values {
+			if !added[v] {
+				added[v] = true
+				res = append(res, v)
+			}
+		}
+	}
+	return res
+}
+
+// WriteLineageEvents writes events as line separated JSON
+func WriteLineageEvents(dst string, events []LineageEvent) error {
+	file, e := os.Create(dst)
+	if e != nil {
+		return e
+	}
+	defer file.Close()
+	writer := bufio.NewWriter(file)
+	for _, event := range events {
+		bytes, e := json.Marshal(event)
+		if e != nil {
+			return e
+		}
+		_, e = writer.Write(bytes)
+		if e != nil {
+			return e
+		}
+		_, e = writer.WriteString("\n")
+		if e != nil {
+			return e
+		}
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,54 @@
+package ops
+
+import (
+	"fmt"
+	"testing"
+)
+
+func lineageRecord(id string, lon float64, lat float64, width float64, height float64) map[string]interface{} {
+	return *parseRecordString(fmt.Sprintf(`{"id":"%s","geometry":[[[[%f,%f],[%f,%f],[%f,%f],[%f,%f],[%f,%f]]]]}`, id,
+		lon, lat, lon+width, lat, lon+width, lat+height, lon, lat+height, lon, lat))
+}
+
+func TestLineage(t *testing.T) {
+	const d = 0.001
+	tracker := NewLineageTracker()
+
+	// Split of 1 into 2 and 3
+	tracker.Removed(lineageRecord("1", 0, 0, 2*d, d))
+	tracker.Added(lineageRecord("2", 0, 0, d, d))
+	tracker.Added(lineageRecord("3", d, 0, d, d))
+
+	// Merge of 4 and 5 into 6
+	tracker.Removed(lineageRecord("4", 0, 10*d, d, d))
+	tracker.Removed(lineageRecord("5", d, 10*d, d, d))
+	tracker.Added(lineageRecord("6", 0, 10*d, 2*d, d))
+
+	// Unrelated records
+	tracker.Removed(lineageRecord("7", 0, 20*d, d, d))
+	tracker.Added(lineageRecord("8", 0, 30*d, d, d))
+
+	lineage := tracker.Detect(0.5, "2018-01-01")
+	if len(lineage.Events) != 2 {
+		t.Fatalf("Expected 2 events, got %v", lineage.Events)
+	}
+	if lineage.Events[0].Type != LineageSplit || len(lineage.Events[0].Successors) != 2 {
+		t.Errorf("Expected split, got %v", lineage.Events[0])
+	}
+	if lineage.Events[1].Type != LineageMerge || len(lineage.Events[1].Predecessors) != 2 {
+		t.Errorf("Expected merge, got %v", lineage.Events[1])
+	}
+	if _, ok := lineage.Successors["7"]; ok {
+		t.Error("Unrelated record should not have successors")
+	}
+
+	row, e := lineage.Apply(lineageRecord("6", 0, 10*d, 2*d, d))
+	if e != nil {
+		t.Fatal(e)
+	}
+	extras, _ := LoadExtras(row["extras"])
+	_, predecessors := extras.GetEnum("predecessors")
+	if len(predecessors) != 2 || predecessors[0] != "4" || predecessors[1] != "5" {
+		t.Errorf("Unexpected predecessors %v", predecessors)
+	}
+}
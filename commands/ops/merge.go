@@ -160,7 +160,23 @@ func cloneMap(src map[string]interface{}) (map[string]interface{}, error) {
 	return res, nil
 }
 
-func Merge(previous map[string]interface{}, latest map[string]interface{}) (map[string]interface{}, error) {
+// MergeOptions configures merging of records
+type MergeOptions struct {
+	Geometry utils.GeometryComparison
+	Policies MergePolicies
+}
+
+// DefaultMergeOptions compares geometry exactly
+var DefaultMergeOptions = MergeOptions{Geometry: utils.ExactComparison}
+
+// Merge merges two versions of a record with exact geometry comparison and extras merge policies,
+// nil policies means that latest values always win
+func Merge(previous map[string]interface{}, latest map[string]interface{}, policies MergePolicies) (map[string]interface{}, error) {
+	return MergeWithOptions(previous, latest, MergeOptions{Geometry: utils.ExactComparison, Policies: policies})
+}
+
+// MergeWithOptions merges two versions of a record
+func MergeWithOptions(previous map[string]interface{}, latest map[string]interface{}, options MergeOptions) (map[string]interface{}, error) {
 
 	// Cloning
 	res, e := cloneMap(latest)
@@ -185,7 +201,13 @@ func Merge(previous map[string]interface{}, latest map[string]interface{}) (map[
 	ex2, ok2 := latest["extras"]
 	if ok1 {
 		if ok2 {
-			r, e := MergeExtras(ex1.(map[string]interface{}), ex2.(map[string]interface{}))
+			r, e := MergeExtrasWithPolicies(ex1.(map[string]interface{}), ex2.(map[string]interface{}), options.Policies)
+			if e != nil {
+				return nil, e
+			}
+			res["extras"] = r
+		} else if len(options.Policies) > 0 {
+			r, e := MergeExtrasWithPolicies(ex1.(map[string]interface{}), map[string]interface{}{}, options.Policies)
 			if e != nil {
 				return nil, e
 			}
@@ -221,7 +243,11 @@ func Merge(previous map[string]interface{}, latest map[string]interface{}) (map[
 				realGeometry2 = goemetry2Src
 			}
 
-			if utils.IsGeometryChanged(realGeometry1.([]interface{}), realGeometry2.([]interface{})) {
+			changed, e := options.Geometry.IsChanged(realGeometry1.([]interface{}), realGeometry2.([]interface{}))
+			if e != nil {
+				return nil, e
+			}
+			if changed {
 				// Geometry was changed copy from latest
 				res["geometry"] = geometry2
 				if ok2Src {
@@ -236,7 +262,8 @@ func Merge(previous map[string]interface{}, latest map[string]interface{}) (map[
 					res["geometry"] = geometry1
 					res["$geometry_src"] = goemetry1Src
 				} else {
-					res["geometry"] = geometry2
+					// Keep previous geometry since changes are within tolerance
+					res["geometry"] = geometry1
 				}
 			}
 		} else {
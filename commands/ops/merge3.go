@@ -0,0 +1,278 @@
+package ops
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+
+	"github.com/statecrafthq/borg/utils"
+)
+
+// Conflict resolution policies
+const (
+	ResolveUpstream = "upstream"
+	ResolveLocal    = "local"
+	ResolveBase     = "base"
+)
+
+// Merge3Options configures three-way merge
+type Merge3Options struct {
+	Geometry utils.GeometryComparison
+	Policy   string
+}
+
+// Conflict is a field changed differently in upstream and local versions. Missing values are nil.
+type Conflict struct {
+	ID       string      `json:"id"`
+	Field    string      `json:"field"`
+	Base     interface{} `json:"base"`
+	Upstream interface{} `json:"upstream"`
+	Local    interface{} `json:"local"`
+	Resolved string      `json:"resolved"`
+}
+
+// fieldValue is a value of a field that could be missing
+type fieldValue struct {
+	value   interface{}
+	present bool
+}
+
+func (v fieldValue) get() interface{} {
+	if !v.present {
+		return nil
+	}
+	return v.value
+}
+
+func isFieldEqual(a fieldValue, b fieldValue, equal func(a interface{}, b interface{}) bool) bool {
+	if a.present != b.present {
+		return false
+	}
+	return !a.present || equal(a.value, b.value)
+}
+
+type merger3 struct {
+	id        string
+	options   Merge3Options
+	conflicts []Conflict
+}
+
+// pick applies non-conflicting change from either side or resolves conflict by policy
+func (m *merger3) pick(field string, base fieldValue, upstream fieldValue, local fieldValue, equal func(a interface{}, b interface{}) bool) fieldValue {
+	if isFieldEqual(upstream, local, equal) {
+		return upstream
+	}
+	if isFieldEqual(upstream, base, equal) {
+		return local
+	}
+	if isFieldEqual(local, base, equal) {
+		return upstream
+	}
+	resolved := upstream
+	if m.options.Policy == ResolveLocal {
+		resolved = local
+	} else if m.options.Policy == ResolveBase {
+		resolved = base
+	}
+	m.conflicts = append(m.conflicts, Conflict{
+		ID:       m.id,
+		Field:    field,
+		Base:     base.get(),
+		Upstream: upstream.get(),
+		Local:    local.get(),
+		Resolved: m.options.Policy,
+	})
+	return resolved
+}
+
+func realGeometry(row map[string]interface{}) interface{} {
+	if src, ok := row["$geometry_src"]; ok {
+		return src
+	}
+	return row["geometry"]
+}
+
+func geometryValue(row map[string]interface{}) fieldValue {
+	if _, ok := row["geometry"]; !ok {
+		return fieldValue{}
+	}
+	return fieldValue{value: row, present: true}
+}
+
+func extrasFieldValues(row map[string]interface{}) (map[string]interface{}, error) {
+	extras, e := LoadExtras(row["extras"])
+	if e != nil {
+		return nil, e
+	}
+	return extrasValues(extras), nil
+}
+
+func setExtrasValue(extras *Extras, key string, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		extras.AppendString(key, v)
+	case []string:
+		extras.AppendEnum(key, v)
+	case float64:
+		extras.AppendFloat(key, v)
+	case int32:
+		extras.AppendInt(key, v)
+	}
+}
+
+func retiredCopy(row map[string]interface{}) (map[string]interface{}, error) {
+	res, e := cloneMap(row)
+	if e != nil {
+		return nil, e
+	}
+	res["retired"] = true
+	return res, nil
+}
+
+// Merge3 merges upstream and local versions of a record that both started from base. Changes are
+// applied per field and per extras key, conflicting changes are resolved by policy and returned.
+// Record missing on one side is treated as retired on that side.
+func Merge3(base *map[string]interface{}, upstream *map[string]interface{}, local *map[string]interface{}, options Merge3Options) (map[string]interface{}, []Conflict, error) {
+	if base == nil && upstream == nil && local == nil {
+		return nil, nil, errors.New("Internal inconsistency")
+	}
+
+	// Added or removed records
+	var b, u, l map[string]interface{}
+	var e error
+	if base == nil {
+		if upstream == nil {
+			res, e := cloneMap(*local)
+			return res, nil, e
+		}
+		if local == nil {
+			res, e := cloneMap(*upstream)
+			return res, nil, e
+		}
+		b = map[string]interface{}{}
+		u = *upstream
+		l = *local
+	} else {
+		b = *base
+		if upstream != nil {
+			u = *upstream
+		} else if u, e = retiredCopy(b); e != nil {
+			return nil, nil, e
+		}
+		if local != nil {
+			l = *local
+		} else if l, e = retiredCopy(b); e != nil {
+			return nil, nil, e
+		}
+	}
+
+	m := &merger3{id: recordID(u), options: options, conflicts: []Conflict{}}
+	res := make(map[string]interface{})
+	deepEqual := func(a interface{}, b interface{}) bool { return reflect.DeepEqual(a, b) }
+
+	// Geometry with its source is merged as a single field, conflicts report real geometry
+	before := len(m.conflicts)
+	var geometryErr error
+	geometry := m.pick("geometry", geometryValue(b), geometryValue(u), geometryValue(l), func(a interface{}, b interface{}) bool {
+		g1 := realGeometry(a.(map[string]interface{}))
+		g2 := realGeometry(b.(map[string]interface{}))
+		changed, e := options.Geometry.IsChanged(g1.([]interface{}), g2.([]interface{}))
+		if e != nil {
+			geometryErr = e
+		}
+		return !changed
+	})
+	if geometryErr != nil {
+		return nil, nil, geometryErr
+	}
+	if geometry.present {
+		row := geometry.value.(map[string]interface{})
+		res["geometry"] = row["geometry"]
+		if src, ok := row["$geometry_src"]; ok {
+			res["$geometry_src"] = src
+		}
+	}
+	if len(m.conflicts) > before {
+		m.conflicts[before].Base = realGeometry(b)
+		m.conflicts[before].Upstream = realGeometry(u)
+		m.conflicts[before].Local = realGeometry(l)
+	}
+
+	// Retired flag, missing flag is the same as not retired
+	_, rb := b["retired"]
+	_, ru := u["retired"]
+	_, rl := l["retired"]
+	if rb || ru || rl {
+		retired := m.pick("retired", fieldValue{isRetired(b), true}, fieldValue{isRetired(u), true}, fieldValue{isRetired(l), true}, deepEqual)
+		res["retired"] = retired.value
+	}
+
+	// Extras by key
+	_, eb := b["extras"]
+	_, eu := u["extras"]
+	_, el := l["extras"]
+	if eb || eu || el {
+		vb, e := extrasFieldValues(b)
+		if e != nil {
+			return nil, nil, e
+		}
+		vu, e := extrasFieldValues(u)
+		if e != nil {
+			return nil, nil, e
+		}
+		vl, e := extrasFieldValues(l)
+		if e != nil {
+			return nil, nil, e
+		}
+		keys := make(map[string]bool)
+		for _, values := range []map[string]interface{}{vb, vu, vl} {
+			for k := range values {
+				keys[k] = true
+			}
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		extras := NewExtras()
+		for _, k := range sorted {
+			value := func(values map[string]interface{}) fieldValue {
+				v, ok := values[k]
+				return fieldValue{v, ok}
+			}
+			r := m.pick("extras."+k, value(vb), value(vu), value(vl), isExtrasValueEqual)
+			if r.present {
+				setExtrasValue(&extras, k, r.value)
+			}
+		}
+		res["extras"] = extras
+	}
+
+	// Rest of fields
+	keys := make(map[string]bool)
+	for _, row := range []map[string]interface{}{b, u, l} {
+		for k := range row {
+			if k != "geometry" && k != "$geometry_src" && k != "retired" && k != "extras" {
+				keys[k] = true
+			}
+		}
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+	for _, k := range sorted {
+		value := func(row map[string]interface{}) fieldValue {
+			v, ok := row[k]
+			return fieldValue{v, ok}
+		}
+		r := m.pick(k, value(b), value(u), value(l), deepEqual)
+		if r.present {
+			res[k] = r.value
+		}
+	}
+
+	return res, m.conflicts, nil
+}
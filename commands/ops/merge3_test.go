@@ -0,0 +1,68 @@
+package ops
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/statecrafthq/borg/utils"
+)
+
+func assertMerge3(t *testing.T, base string, upstream string, local string, policy string, expected string, conflicts int) {
+	parse := func(src string) *map[string]interface{} {
+		if src == "" {
+			return nil
+		}
+		return parseRecord(t, src)
+	}
+	res, c, e := Merge3(parse(base), parse(upstream), parse(local), Merge3Options{Geometry: utils.ExactComparison, Policy: policy})
+	if e != nil {
+		t.Fatal(e)
+	}
+	if len(c) != conflicts {
+		t.Errorf("Expected %d conflicts, got %v", conflicts, c)
+	}
+	marshaled, e := json.Marshal(res)
+	if e != nil {
+		t.Fatal(e)
+	}
+	var resDict map[string]interface{}
+	json.Unmarshal(marshaled, &resDict)
+	expDict := *parseRecord(t, expected)
+	changed, e := utils.IsChanged(resDict, expDict)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if changed {
+		t.Errorf("Expected %s, got %s", expected, string(marshaled))
+	}
+}
+
+func TestMerge3(t *testing.T) {
+	base := `{"id":"1","geometry":[[[[0,0],[1,0],[1,1],[0,0]]]],"extras":{"strings":[{"key":"a","value":"x"},{"key":"b","value":"x"}]}}`
+
+	// Upstream geometry change and local extras correction are both applied
+	assertMerge3(t, base,
+		`{"id":"1","geometry":[[[[0,0],[2,0],[1,1],[0,0]]]],"extras":{"strings":[{"key":"a","value":"x"},{"key":"b","value":"x"}]}}`,
+		`{"id":"1","geometry":[[[[0,0],[1,0],[1,1],[0,0]]]],"extras":{"strings":[{"key":"a","value":"y"},{"key":"b","value":"x"}],"ints":[{"key":"c","value":1}]}}`,
+		ResolveUpstream,
+		`{"id":"1","geometry":[[[[0,0],[2,0],[1,1],[0,0]]]],"extras":{"strings":[{"key":"a","value":"y"},{"key":"b","value":"x"}],"ints":[{"key":"c","value":1}]}}`,
+		0)
+
+	// Conflicting extras key
+	upstream := `{"id":"1","geometry":[[[[0,0],[1,0],[1,1],[0,0]]]],"extras":{"strings":[{"key":"a","value":"u"},{"key":"b","value":"u"}]}}`
+	local := `{"id":"1","geometry":[[[[0,0],[1,0],[1,1],[0,0]]]],"extras":{"strings":[{"key":"a","value":"l"},{"key":"b","value":"x"}]}}`
+	assertMerge3(t, base, upstream, local, ResolveUpstream,
+		`{"id":"1","geometry":[[[[0,0],[1,0],[1,1],[0,0]]]],"extras":{"strings":[{"key":"a","value":"u"},{"key":"b","value":"u"}]}}`, 1)
+	assertMerge3(t, base, upstream, local, ResolveLocal,
+		`{"id":"1","geometry":[[[[0,0],[1,0],[1,1],[0,0]]]],"extras":{"strings":[{"key":"a","value":"l"},{"key":"b","value":"u"}]}}`, 1)
+	assertMerge3(t, base, upstream, local, ResolveBase,
+		`{"id":"1","geometry":[[[[0,0],[1,0],[1,1],[0,0]]]],"extras":{"strings":[{"key":"a","value":"x"},{"key":"b","value":"u"}]}}`, 1)
+
+	// Removed upstream record is retired while keeping local changes
+	assertMerge3(t, base, "", local, ResolveUpstream,
+		`{"id":"1","retired":true,"geometry":[[[[0,0],[1,0],[1,1],[0,0]]]],"extras":{"strings":[{"key":"a","value":"l"},{"key":"b","value":"x"}]}}`, 0)
+
+	// New records
+	assertMerge3(t, "", upstream, "", ResolveUpstream, upstream, 0)
+	assertMerge3(t, "", "", local, ResolveUpstream, local, 0)
+}
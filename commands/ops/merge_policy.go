@@ -0,0 +1,138 @@
+package ops
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+)
+
+// Merge policies for extras keys
+const (
+	PolicyLatestWins    = "latest-wins"
+	PolicyPreviousWins  = "previous-wins"
+	PolicyUnion         = "union"
+	PolicyDropIfMissing = "drop-if-missing"
+	PolicyMax           = "max"
+	PolicyMin           = "min"
+)
+
+// MergePolicies maps extras keys to merge policies. Keys without policy use latest-wins with
+// carrying forward keys missing in latest.
+type MergePolicies map[string]string
+
+// LoadMergePolicies reads policies from JSON file
+func LoadMergePolicies(src string) (MergePolicies, error) {
+	data, e := ioutil.ReadFile(src)
+	if e != nil {
+		return nil, e
+	}
+	var res MergePolicies
+	e = json.Unmarshal(data, &res)
+	if e != nil {
+		return nil, e
+	}
+	for k, p := range res {
+		switch p {
+		case PolicyLatestWins, PolicyPreviousWins, PolicyUnion, PolicyDropIfMissing, PolicyMax, PolicyMin:
+		default:
+			return nil, errors.New("Unknown merge policy '" + p + "' of key " + k)
+		}
+	}
+	return res, nil
+}
+
+var extrasTypes = []string{"floats", "ints", "strings", "enums"}
+
+// findExtrasEntry searches for a raw extras record of a key
+func findExtrasEntry(extras map[string]interface{}, key string) (string, map[string]interface{}) {
+	for _, t := range extrasTypes {
+		values, ok := extras[t].([]interface{})
+		if !ok {
+			continue
+		}
+		for _, v := range values {
+			r := v.(map[string]interface{})
+			if r["key"] == key {
+				return t, r
+			}
+		}
+	}
+	return "", nil
+}
+
+func removeExtrasEntry(extras map[string]interface{}, key string) {
+	for _, t := range extrasTypes {
+		values, ok := extras[t].([]interface{})
+		if !ok {
+			continue
+		}
+		res := make([]interface{}, 0)
+		for _, v := range values {
+			if v.(map[string]interface{})["key"] != key {
+				res = append(res, v)
+			}
+		}
+		extras[t] = res
+	}
+}
+
+func putExtrasEntry(extras map[string]interface{}, t string, entry map[string]interface{}) {
+	removeExtrasEntry(extras, entry["key"].(string))
+	values, _ := extras[t].([]interface{})
+	extras[t] = append(values, entry)
+}
+
+func unionEnums(a []interface{}, b []interface{}) []interface{} {
+	res := make([]interface{}, 0)
+	added := make(map[interface{}]bool)
+	for _, values := range [][]interface{}{a, b} {
+		for _, v := range values {
+			if !added[v] {
+				added[v] = true
+				res = append(res, v)
+			}
+		}
+	}
+	return res
+}
+
+// applyMergePolicy changes merged extras according to policy of a key
+func applyMergePolicy(previous map[string]interface{}, latest map[string]interface{}, merged map[string]interface{}, key string, policy string) {
+	pt, pe := findExtrasEntry(previous, key)
+	lt, le := findExtrasEntry(latest, key)
+	switch policy {
+	case PolicyPreviousWins:
+		if pe != nil {
+			putExtrasEntry(merged, pt, pe)
+		}
+	case PolicyDropIfMissing:
+		if le == nil {
+			removeExtrasEntry(merged, key)
+		}
+	case PolicyUnion:
+		if pe != nil && le != nil && pt == "enums" && lt == "enums" {
+			value := unionEnums(pe["value"].([]interface{}), le["value"].([]interface{}))
+			putExtrasEntry(merged, lt, map[string]interface{}{"key": key, "value": value})
+		}
+	case PolicyMax, PolicyMin:
+		if pe != nil && le != nil && pt == lt && (pt == "floats" || pt == "ints") {
+			pv := pe["value"].(float64)
+			lv := le["value"].(float64)
+			if (policy == PolicyMax && pv > lv) || (policy == PolicyMin && pv < lv) {
+				putExtrasEntry(merged, pt, pe)
+			}
+		}
+	}
+}
+
+// MergeExtrasWithPolicies merges extras and then applies per key policies
+func MergeExtrasWithPolicies(a map[string]interface{}, b map[string]interface{}, policies MergePolicies) (map[string]interface{}, error) {
+	res, e := MergeExtras(a, b)
+	if e != nil {
+		return nil, e
+	}
+	for key, policy := range policies {
+		applyMergePolicy(a, b, res, key, policy)
+	}
+	return res, nil
+}
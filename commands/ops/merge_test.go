@@ -3,9 +3,27 @@ package ops
 import (
 	"encoding/json"
 	"testing"
+
+	"github.com/statecrafthq/borg/utils"
 )
 
 func assertMerge(t *testing.T, old string, new string, res string) {
+	assertMergeWithPolicies(t, old, new, res, nil)
+}
+
+func assertMergeWithPolicies(t *testing.T, old string, new string, res string, policies MergePolicies) {
+	assertMergeWith(t, old, new, res, func(a map[string]interface{}, b map[string]interface{}) (map[string]interface{}, error) {
+		return Merge(a, b, policies)
+	})
+}
+
+func assertMergeWithOptions(t *testing.T, old string, new string, res string, options MergeOptions) {
+	assertMergeWith(t, old, new, res, func(a map[string]interface{}, b map[string]interface{}) (map[string]interface{}, error) {
+		return MergeWithOptions(a, b, options)
+	})
+}
+
+func assertMergeWith(t *testing.T, old string, new string, res string, merge func(a map[string]interface{}, b map[string]interface{}) (map[string]interface{}, error)) {
 	oldDict := make(map[string]interface{})
 	newDict := make(map[string]interface{})
 	e := json.Unmarshal([]byte(old), &oldDict)
@@ -18,7 +36,7 @@ func assertMerge(t *testing.T, old string, new string, res string) {
 		t.Error(e)
 		return
 	}
-	resDict, e := Merge(oldDict, newDict)
+	resDict, e := merge(oldDict, newDict)
 	if e != nil {
 		t.Error(e)
 		return
@@ -124,10 +142,50 @@ func TestGeometryMerge(t *testing.T) {
 	// Should proritize latest over previous
 	assertMerge(t, `{"geometry":[[[[1,1]]]],"$geometry_src":[[[[1,2]]]]}`, `{"geometry":[[[[1,2]]]],"$geometry_src":[[[[1,2]]]]}`, `{"geometry":[[[[1,2]]]],"$geometry_src":[[[[1,2]]]]}`)
 	assertMerge(t, `{"geometry":[[[[1,1]]]],"$geometry_src":[[[[1,2]]]]}`, `{"geometry":[[[[1,2]]]],"$geometry_src":[[[[1,2]]]]}`, `{"geometry":[[[[1,2]]]],"$geometry_src":[[[[1,2]]]]}`)
+
+	// Should keep previous geometry if changes are within tolerance
+	normalized := MergeOptions{Geometry: utils.GeometryComparison{Mode: utils.GeometryNormalized, Tolerance: 0.01}}
+	assertMergeWithOptions(t, `{"geometry":[[[[0,0],[1,0],[1,1],[0,0]]]]}`, `{"geometry":[[[[1,0],[1,1],[0,0],[1,0]]]]}`, `{"geometry":[[[[0,0],[1,0],[1,1],[0,0]]]]}`, normalized)
+	assertMergeWithOptions(t, `{"geometry":[[[[0,0],[1,0],[1,1],[0,0]]]]}`, `{"geometry":[[[[0,0],[2,0],[1,1],[0,0]]]]}`, `{"geometry":[[[[0,0],[2,0],[1,1],[0,0]]]]}`, normalized)
 }
+
 func TestFieldTypeChange(t *testing.T) {
 	assertMerge(t,
 		`{"extras": {"ints":[{"key": "key_1", "value": 123 }]}}`,
 		`{"extras": {"floats":[{"key": "key_1", "value": 123 }]}}`,
 		`{"extras": {"floats":[{"key": "key_1", "value": 123 }],"ints":[]}}`)
 }
+
+func TestExtrasMergePolicies(t *testing.T) {
+	policies := MergePolicies{
+		"zoning": PolicyDropIfMissing,
+		"notes":  PolicyPreviousWins,
+		"tags":   PolicyUnion,
+		"height": PolicyMax,
+		"floors": PolicyMin,
+	}
+	assertMergeWithPolicies(t,
+		`{"extras": {"enums":[{"key": "zoning", "value": ["R1"]}]}}`,
+		`{"extras": {"enums":[]}}`,
+		`{"extras": {"enums":[]}}`, policies)
+	assertMergeWithPolicies(t,
+		`{"extras": {"enums":[{"key": "zoning", "value": ["R1"]}]}}`,
+		`{}`,
+		`{"extras": {"enums":[]}}`, policies)
+	assertMergeWithPolicies(t,
+		`{"extras": {"strings":[{"key": "notes", "value": "checked"}]}}`,
+		`{"extras": {"strings":[{"key": "notes", "value": "imported"}]}}`,
+		`{"extras": {"strings":[{"key": "notes", "value": "checked"}]}}`, policies)
+	assertMergeWithPolicies(t,
+		`{"extras": {"enums":[{"key": "tags", "value": ["a", "b"]}]}}`,
+		`{"extras": {"enums":[{"key": "tags", "value": ["b", "c"]}]}}`,
+		`{"extras": {"enums":[{"key": "tags", "value": ["a", "b", "c"]}]}}`, policies)
+	assertMergeWithPolicies(t,
+		`{"extras": {"floats":[{"key": "height", "value": 12.5}], "ints":[{"key": "floors", "value": 3}]}}`,
+		`{"extras": {"floats":[{"key": "height", "value": 10}], "ints":[{"key": "floors", "value": 4}]}}`,
+		`{"extras": {"floats":[{"key": "height", "value": 12.5}], "ints":[{"key": "floors", "value": 3}]}}`, policies)
+	assertMergeWithPolicies(t,
+		`{"extras": {"floats":[{"key": "height", "value": 8}]}}`,
+		`{"extras": {"floats":[{"key": "height", "value": 10}]}}`,
+		`{"extras": {"floats":[{"key": "height", "value": 10}]}}`, policies)
+}
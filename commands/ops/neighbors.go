@@ -0,0 +1,122 @@
+package ops
+
+import (
+	"math"
+
+	"github.com/statecrafthq/borg/geometry"
+)
+
+// Neighbor is an adjacent parcel with length of a shared boundary
+type Neighbor struct {
+	Index  int
+	Length float64
+}
+
+// sharedEdgeLength calculates length of part of edge b that lies along edge a within tolerance
+func sharedEdgeLength(a geometry.Edge2D, b geometry.Edge2D, tolerance float64) float64 {
+	l := a.Length()
+	if l < 1e-9 {
+		return 0
+	}
+	dx := (a.End.X - a.Start.X) / l
+	dy := (a.End.Y - a.Start.Y) / l
+	bsx := b.Start.X - a.Start.X
+	bsy := b.Start.Y - a.Start.Y
+	bex := b.End.X - a.Start.X
+	bey := b.End.Y - a.Start.Y
+	if math.Abs(bsx*dy-bsy*dx) > tolerance || math.Abs(bex*dy-bey*dx) > tolerance {
+		return 0
+	}
+	t1 := bsx*dx + bsy*dy
+	t2 := bex*dx + bey*dy
+	return math.Max(0, math.Min(l, math.Max(t1, t2))-math.Max(0, math.Min(t1, t2)))
+}
+
+func allEdges(multipoly geometry.Multipolygon2D) []geometry.Edge2D {
+	res := make([]geometry.Edge2D, 0)
+	for _, p := range multipoly.Polygons {
+		res = append(res, p.Edges()...)
+		for _, h := range p.Holes {
+			res = append(res, geometry.NewSimplePolygon(h).Edges()...)
+		}
+	}
+	return res
+}
+
+// SharedLength calculates length of common boundary of two shapes
+func SharedLength(a geometry.Multipolygon2D, b geometry.Multipolygon2D, tolerance float64) float64 {
+	return sharedLength(allEdges(a), allEdges(b), tolerance)
+}
+
+func sharedLength(edgesA []geometry.Edge2D, edgesB []geometry.Edge2D, tolerance float64) float64 {
+	res := 0.0
+	for _, ea := range edgesA {
+		eab := ea.Bounds().Expand(tolerance)
+		for _, eb := range edgesB {
+			ebb := eb.Bounds()
+			if ebb.MinX > eab.MaxX || ebb.MaxX < eab.MinX || ebb.MinY > eab.MaxY || ebb.MaxY < eab.MinY {
+				continue
+			}
+			res += sharedEdgeLength(ea, eb, tolerance)
+		}
+	}
+	return res
+}
+
+// BuildAdjacency finds all pairs of shapes that share at least minLength of boundary
+func BuildAdjacency(shapes []geometry.Multipolygon2D, tolerance float64, minLength float64) [][]Neighbor {
+	bounds := make([]geometry.Bounds, len(shapes))
+	edges := make([][]geometry.Edge2D, len(shapes))
+	index := geometry.NewGridIndex(100)
+	for i, s := range shapes {
+		bounds[i] = s.Bounds().Expand(tolerance)
+		edges[i] = allEdges(s)
+		index.Insert(bounds[i])
+	}
+	res := make([][]Neighbor, len(shapes))
+	for i := range shapes {
+		for _, j := range index.Query(bounds[i]) {
+			if j <= i {
+				continue
+			}
+			l := sharedLength(edges[i], edges[j], tolerance)
+			if l >= minLength {
+				res[i] = append(res[i], Neighbor{Index: j, Length: l})
+				res[j] = append(res[j], Neighbor{Index: i, Length: l})
+			}
+		}
+	}
+	return res
+}
+
+// ConnectedComponents assigns component number to every shape. Only edges accepted
+// by connected are followed.
+func ConnectedComponents(adjacency [][]Neighbor, connected func(a int, b int) bool) []int {
+	parent := make([]int, len(adjacency))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i, neighbors := range adjacency {
+		for _, n := range neighbors {
+			if connected(i, n.Index) {
+				a := find(i)
+				b := find(n.Index)
+				if a != b {
+					parent[b] = a
+				}
+			}
+		}
+	}
+	res := make([]int, len(adjacency))
+	for i := range res {
+		res[i] = find(i)
+	}
+	return res
+}
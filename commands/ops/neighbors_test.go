@@ -0,0 +1,40 @@
+package ops
+
+import (
+	"testing"
+
+	"github.com/statecrafthq/borg/geometry"
+	"github.com/stretchr/testify/assert"
+)
+
+func square(x float64, y float64, size float64) geometry.Multipolygon2D {
+	return geometry.Multipolygon2D{Polygons: []geometry.Polygon2D{geometry.NewSimplePolygon([]geometry.Point2D{{X: x, Y: y}, {X: x + size, Y: y}, {X: x + size, Y: y + size}, {X: x, Y: y + size}})}}
+}
+
+func TestSharedLength(t *testing.T) {
+	assert.InDelta(t, 10, SharedLength(square(0, 0, 10), square(10, 0, 10), 0.1), 0.000001)
+	assert.InDelta(t, 5, SharedLength(square(0, 0, 10), square(10.05, 5, 10), 0.1), 0.000001)
+	assert.InDelta(t, 0, SharedLength(square(0, 0, 10), square(10, 10, 10), 0.1), 0.000001)
+	assert.InDelta(t, 0, SharedLength(square(0, 0, 10), square(11, 0, 10), 0.1), 0.000001)
+}
+
+func TestBuildAdjacency(t *testing.T) {
+	shapes := []geometry.Multipolygon2D{
+		square(0, 0, 10),
+		square(10, 0, 10),
+		square(20, 0, 10),
+		square(30, 10, 10),
+	}
+	adjacency := BuildAdjacency(shapes, 0.1, 1)
+	assert.Equal(t, []Neighbor{{Index: 1, Length: 10}}, adjacency[0])
+	assert.Equal(t, 2, len(adjacency[1]))
+	assert.Equal(t, 0, len(adjacency[3]))
+
+	all := ConnectedComponents(adjacency, func(a int, b int) bool { return true })
+	assert.Equal(t, all[0], all[2])
+	assert.NotEqual(t, all[0], all[3])
+
+	split := ConnectedComponents(adjacency, func(a int, b int) bool { return a != 2 && b != 2 })
+	assert.Equal(t, split[0], split[1])
+	assert.NotEqual(t, split[0], split[2])
+}
@@ -1,24 +1,285 @@
 package ops
 
-import "github.com/statecrafthq/borg/commands/ops/simplify"
+import (
+	"math"
+	"sort"
 
-func OptimizeLine(line [][]float64) [][]float64 {
-	res := simplify.Simplify(line, 0.00001, false)
-	// Avoid too simplifyed lines
-	if len(res) <= 3 {
-		return line
+	"github.com/statecrafthq/borg/geometry"
+)
+
+// VertexKey is a rounded coordinate that is used to find vertices shared between records
+type VertexKey struct {
+	X int64
+	Y int64
+}
+
+// NewVertexKey rounds coordinate to 1e-7 degrees (about 1cm)
+func NewVertexKey(p []float64) VertexKey {
+	return VertexKey{X: int64(math.Round(p[0] * 1e7)), Y: int64(math.Round(p[1] * 1e7))}
+}
+
+// CollectVertices returns distinct vertices of a multipolygon
+func CollectVertices(multipoly [][][][]float64) []VertexKey {
+	seen := make(map[VertexKey]bool)
+	res := make([]VertexKey, 0)
+	for _, poly := range multipoly {
+		for _, ring := range poly {
+			for _, p := range ring {
+				k := NewVertexKey(p)
+				if !seen[k] {
+					seen[k] = true
+					res = append(res, k)
+				}
+			}
+		}
+	}
+	return res
+}
+
+// sharedVertexCell is a size of grid cell in vertex key units (about 100m) that is used to find T-junctions
+const sharedVertexCell = 10000
+
+type vertexCell struct {
+	X int64
+	Y int64
+}
+
+func newVertexCell(k VertexKey) vertexCell {
+	return vertexCell{X: floorDiv(k.X, sharedVertexCell), Y: floorDiv(k.Y, sharedVertexCell)}
+}
+
+func floorDiv(a int64, b int64) int64 {
+	res := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		res--
 	}
 	return res
 }
 
-func OptimizePolygon(multipoly [][][][]float64) [][][][]float64 {
+// isOnSegment checks if vertex lies on segment within one key unit (about 1cm)
+func isOnSegment(k VertexKey, a VertexKey, b VertexKey) bool {
+	if k == a || k == b {
+		return false
+	}
+	p := geometry.Point2D{X: float64(k.X), Y: float64(k.Y)}
+	return p.SegmentDistance(geometry.Point2D{X: float64(a.X), Y: float64(a.Y)}, geometry.Point2D{X: float64(b.X), Y: float64(b.Y)}) <= 1
+}
+
+// SharedVertices returns vertices that should be locked during simplification to keep boundaries between
+// shapes: vertices that are present in several shapes, vertices of one shape lying on an edge of another
+// one (T-junctions) and end points of such edges.
+func SharedVertices(shapes [][][][][]float64) map[VertexKey]bool {
+	shared := make(map[VertexKey]bool)
+	owners := make(map[VertexKey]int)
+	cells := make(map[vertexCell][]VertexKey)
+	for i, shape := range shapes {
+		for _, k := range CollectVertices(shape) {
+			if _, ok := owners[k]; ok {
+				shared[k] = true
+				continue
+			}
+			owners[k] = i
+			c := newVertexCell(k)
+			cells[c] = append(cells[c], k)
+		}
+	}
+
+	// T-junctions
+	for i, shape := range shapes {
+		for _, poly := range shape {
+			for _, ring := range poly {
+				for j := 1; j < len(ring); j++ {
+					a := NewVertexKey(ring[j-1])
+					b := NewVertexKey(ring[j])
+					ca := newVertexCell(a)
+					cb := newVertexCell(b)
+					for x := minInt64(ca.X, cb.X); x <= maxInt64(ca.X, cb.X); x++ {
+						for y := minInt64(ca.Y, cb.Y); y <= maxInt64(ca.Y, cb.Y); y++ {
+							for _, k := range cells[vertexCell{X: x, Y: y}] {
+								if owners[k] == i || !isOnSegment(k, a, b) {
+									continue
+								}
+								shared[k] = true
+								shared[a] = true
+								shared[b] = true
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return shared
+}
+
+func minInt64(a int64, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt64(a int64, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// SimplifyStats describes changes made by simplification
+type SimplifyStats struct {
+	VerticesBefore int
+	VerticesAfter  int
+	AreaBefore     float64
+	AreaAfter      float64
+}
+
+// simplifyChain runs Douglas-Peucker on a chain of vertices keeping it's end points
+func simplifyChain(points []geometry.Point2D, chain []int, tolerance float64, keep []bool) {
+	if len(chain) < 3 {
+		return
+	}
+	first := points[chain[0]]
+	last := points[chain[len(chain)-1]]
+	maxDist := 0.0
+	index := 0
+	for i := 1; i < len(chain)-1; i++ {
+		d := points[chain[i]].SegmentDistance(first, last)
+		if d > maxDist {
+			maxDist = d
+			index = i
+		}
+	}
+	if maxDist > tolerance {
+		keep[chain[index]] = true
+		simplifyChain(points, chain[:index+1], tolerance, keep)
+		simplifyChain(points, chain[index:], tolerance, keep)
+	}
+}
+
+// simplifyRing simplifies open ring and never removes locked vertices. Returns flags of kept vertices.
+func simplifyRing(points []geometry.Point2D, locked []bool, tolerance float64) []bool {
+	n := len(points)
+	keep := make([]bool, n)
+	anchors := make([]int, 0)
+	for i := range points {
+		if locked[i] {
+			keep[i] = true
+			anchors = append(anchors, i)
+		}
+	}
+
+	// Rings without shared vertices are split by first and the most distant vertices
+	if len(anchors) < 2 {
+		far := 0
+		for i := range points {
+			if points[i].DistanceSq(points[0]) > points[far].DistanceSq(points[0]) {
+				far = i
+			}
+		}
+		keep[0] = true
+		keep[far] = true
+		anchors = []int{0}
+		if far != 0 {
+			anchors = append(anchors, far)
+		}
+		for i := range points {
+			if locked[i] && i != 0 && i != far {
+				anchors = append(anchors, i)
+			}
+		}
+		sort.Ints(anchors)
+	}
+
+	for a := range anchors {
+		start := anchors[a]
+		end := anchors[(a+1)%len(anchors)]
+		if end <= start {
+			end += n
+		}
+		chain := make([]int, 0)
+		for i := start; i <= end; i++ {
+			chain = append(chain, i%n)
+		}
+		simplifyChain(points, chain, tolerance, keep)
+	}
+
+	kept := 0
+	for _, k := range keep {
+		if k {
+			kept++
+		}
+	}
+	if kept < 3 {
+		for i := range keep {
+			keep[i] = true
+		}
+	}
+	return keep
+}
+
+// SimplifyPolygon simplifies geometry with tolerance in meters. Vertices that are locked (shared with
+// neighbors) are always preserved, so shared boundaries are kept as is. Polygons that become invalid
+// after simplification are left untouched.
+func SimplifyPolygon(multipoly [][][][]float64, tolerance float64, locked func(p []float64) bool) ([][][][]float64, SimplifyStats) {
+	geo := geometry.NewGeoMultipolygon(multipoly)
+	proj := geometry.NewProjection(geo.Center())
+	stats := SimplifyStats{AreaBefore: math.Abs(geo.Area())}
 	res := make([][][][]float64, 0)
 	for _, poly := range multipoly {
-		p := make([][][]float64, 0)
+		simplified := make([][][]float64, 0)
+		projected := geometry.Polygon2D{}
+		for r, ring := range poly {
+			stats.VerticesBefore += len(ring)
+
+			// Rings are closed
+			open := ring
+			if len(ring) > 1 && ring[0][0] == ring[len(ring)-1][0] && ring[0][1] == ring[len(ring)-1][1] {
+				open = ring[:len(ring)-1]
+			}
+			points := make([]geometry.Point2D, len(open))
+			isLocked := make([]bool, len(open))
+			for i, p := range open {
+				points[i] = geometry.PointGeo{Longitude: p[0], Latitude: p[1]}.Project(proj)
+				isLocked[i] = locked(p)
+			}
+			keep := make([]bool, len(open))
+			if len(open) > 3 {
+				keep = simplifyRing(points, isLocked, tolerance)
+			} else {
+				for i := range keep {
+					keep[i] = true
+				}
+			}
+
+			resRing := make([][]float64, 0)
+			resPoints := make([]geometry.Point2D, 0)
+			for i := range open {
+				if keep[i] {
+					resRing = append(resRing, open[i])
+					resPoints = append(resPoints, points[i])
+				}
+			}
+			if len(open) < len(ring) {
+				resRing = append(resRing, open[0])
+			}
+			simplified = append(simplified, resRing)
+			if r == 0 {
+				projected.Polygon = resPoints
+			} else {
+				projected.Holes = append(projected.Holes, resPoints)
+			}
+		}
+		if !projected.IsSimple() {
+			simplified = poly
+		}
+		res = append(res, simplified)
+	}
+	for _, poly := range res {
 		for _, ring := range poly {
-			p = append(p, OptimizeLine(ring))
+			stats.VerticesAfter += len(ring)
 		}
-		res = append(res, p)
 	}
-	return res
+	stats.AreaAfter = math.Abs(geometry.NewGeoMultipolygon(res).Area())
+	return res, stats
 }
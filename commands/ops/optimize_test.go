@@ -2,31 +2,45 @@ package ops
 
 import (
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
-func TestSimplify(t *testing.T) {
-	coords := [][]float64{
-		{-74.00762243660023, 40.70980127030019},
-		{-74.00754574127258, 40.70976248032602},
-		{-74.00747424759155, 40.70972631999469},
-		{-74.00747157803515, 40.70972925486847},
-		{-74.00738856093687, 40.70968726535479},
-		{-74.00730976505876, 40.70964741697701},
-		{-74.00748959017605, 40.70946256265158},
-		{-74.00779024860698, 40.709634951687526},
-		{-74.00762590743949, 40.709803026169574},
-		{-74.00762243660023, 40.70980127030019},
-	}
-	optimized := OptimizeLine(coords)
+func TestSimplifyPolygon(t *testing.T) {
+	// ~100m x 100m square with almost collinear vertices on each side
+	poly := [][][][]float64{{{
+		{-74.0, 40.7}, {-73.9994, 40.700001}, {-73.9988, 40.7}, {-73.9988, 40.7009}, {-73.99881, 40.7018},
+		{-73.9994, 40.7018}, {-74.0, 40.7018}, {-74.000001, 40.7009}, {-74.0, 40.7},
+	}}}
+	unlocked := func(p []float64) bool { return false }
+	res, stats := SimplifyPolygon(poly, 1, unlocked)
+	assert.Equal(t, 5, len(res[0][0]))
+	assert.Equal(t, res[0][0][0], res[0][0][4])
+	assert.Equal(t, 9, stats.VerticesBefore)
+	assert.Equal(t, 5, stats.VerticesAfter)
+	assert.InEpsilon(t, stats.AreaBefore, stats.AreaAfter, 0.01)
+
+	// Shared vertices are preserved
+	shared := NewVertexKey([]float64{-73.9988, 40.7009})
+	res, _ = SimplifyPolygon(poly, 1, func(p []float64) bool { return NewVertexKey(p) == shared })
+	assert.Equal(t, 6, len(res[0][0]))
 
-	if len(optimized) != 5 {
-		t.Error("Expected 5 points, but got: " + string(len(coords)))
-	}
+	// Small tolerance removes only collinear vertices
+	res, _ = SimplifyPolygon(poly, 0.01, unlocked)
+	assert.Equal(t, 8, len(res[0][0]))
 
-	if optimized[0][0] != optimized[len(optimized)-1][0] || optimized[0][1] != optimized[len(optimized)-1][1] {
-		t.Error("First and last points doesn't match!")
-	}
+	assert.Equal(t, 8, len(CollectVertices(poly)))
+}
 
-	// t.Log(coords)
-	// t.Log(optimized)
+func TestSharedVertices(t *testing.T) {
+	// Square with neighbor that touches only half of it's right side
+	a := [][][][]float64{{{{-74.0, 40.7}, {-73.999, 40.7}, {-73.999, 40.702}, {-74.0, 40.702}, {-74.0, 40.7}}}}
+	b := [][][][]float64{{{{-73.999, 40.7}, {-73.998, 40.7}, {-73.998, 40.701}, {-73.999, 40.701}, {-73.999, 40.7}}}}
+	shared := SharedVertices([][][][][]float64{a, b})
+	assert.True(t, shared[NewVertexKey([]float64{-73.999, 40.7})])
+	assert.True(t, shared[NewVertexKey([]float64{-73.999, 40.701})])
+	assert.True(t, shared[NewVertexKey([]float64{-73.999, 40.702})])
+	assert.False(t, shared[NewVertexKey([]float64{-74.0, 40.7})])
+	assert.False(t, shared[NewVertexKey([]float64{-73.998, 40.701})])
+	assert.Equal(t, 3, len(shared))
 }
@@ -63,6 +63,8 @@ func DiffReaderSorted(a string, aLines int, b string, bLines int, handler func(a
 				read++
 				if len(line) > 0 {
 					srcLoaded = true
+					// Unmarshal reuses existing map and would keep keys of a previous record
+					srcLine = make(map[string]interface{})
 					e = json.Unmarshal(line, &srcLine)
 					if e != nil {
 						return e
@@ -83,6 +85,8 @@ func DiffReaderSorted(a string, aLines int, b string, bLines int, handler func(a
 				read++
 				if len(line) > 0 {
 					updLoaded = true
+					// Unmarshal reuses existing map and would keep keys of a previous record
+					updLine = make(map[string]interface{})
 					e = json.Unmarshal(line, &updLine)
 					if e != nil {
 						return e
@@ -187,6 +191,111 @@ func DiffReader(a string, b string, handler func(a *map[string]interface{}, b *m
 	return DiffReaderSorted("./tmp/a.ols", aLines, "./tmp/b.ols", bLines, handler)
 }
 
+// sortedRecords iterates over records of a sorted file
+type sortedRecords struct {
+	reader  *bufio.Reader
+	current map[string]interface{}
+	eof     bool
+}
+
+func (r *sortedRecords) next() error {
+	r.current = nil
+	for !r.eof {
+		line, e := r.reader.ReadBytes('\n')
+		if e != nil {
+			if e != io.EOF {
+				return e
+			}
+			r.eof = true
+		}
+		if len(line) > 0 {
+			return json.Unmarshal(line, &r.current)
+		}
+	}
+	return nil
+}
+
+func (r *sortedRecords) id() string {
+	return r.current["id"].(string)
+}
+
+// DiffReader3 iterates over three datasets matching records by id. Missing records are passed as nil.
+func DiffReader3(a string, b string, c string, handler func(a *map[string]interface{}, b *map[string]interface{}, c *map[string]interface{}) error) error {
+
+	//
+	// Preflight
+	//
+
+	e := utils.PrepareTemp()
+	if e != nil {
+		return e
+	}
+	defer utils.ClearTemp()
+
+	files := []string{a, b, c}
+	readers := make([]*sortedRecords, len(files))
+	total := 0
+	for i, f := range files {
+		sorted := fmt.Sprintf("./tmp/%d.ols", i)
+		lines, e := SortFile(f, sorted)
+		if e != nil {
+			return e
+		}
+		total += lines
+		file, e := os.Open(sorted)
+		if e != nil {
+			return e
+		}
+		defer file.Close()
+		readers[i] = &sortedRecords{reader: bufio.NewReader(file)}
+		e = readers[i].next()
+		if e != nil {
+			return e
+		}
+	}
+
+	//
+	// Matching
+	//
+
+	bar := pb.StartNew(total)
+	defer bar.Finish()
+	read := 0
+	for {
+		// Smallest id
+		id := ""
+		found := false
+		for _, r := range readers {
+			if r.current != nil && (!found || r.id() < id) {
+				id = r.id()
+				found = true
+			}
+		}
+		if !found {
+			break
+		}
+
+		records := make([]*map[string]interface{}, len(readers))
+		for i, r := range readers {
+			if r.current != nil && r.id() == id {
+				current := r.current
+				records[i] = &current
+				read++
+				e = r.next()
+				if e != nil {
+					return e
+				}
+			}
+		}
+		bar.Set(read)
+		e = handler(records[0], records[1], records[2])
+		if e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
 func RecordReader(src string, handler func(row map[string]interface{}) error) error {
 	// Opening file
 	file, e := os.Open(src)
@@ -0,0 +1,47 @@
+package ops
+
+import (
+	"encoding/csv"
+	"os"
+	"sync"
+)
+
+// CSVReport is a CSV file that could be written from concurrent transformers
+type CSVReport struct {
+	lock   sync.Mutex
+	file   *os.File
+	writer *csv.Writer
+}
+
+// NewCSVReport creates report file and writes header
+func NewCSVReport(dst string, header []string) (*CSVReport, error) {
+	file, e := os.Create(dst)
+	if e != nil {
+		return nil, e
+	}
+	res := &CSVReport{file: file, writer: csv.NewWriter(file)}
+	e = res.Write(header)
+	if e != nil {
+		file.Close()
+		return nil, e
+	}
+	return res, nil
+}
+
+// Write appends record to a report
+func (report *CSVReport) Write(record []string) error {
+	report.lock.Lock()
+	defer report.lock.Unlock()
+	return report.writer.Write(record)
+}
+
+// Close flushes and closes report file
+func (report *CSVReport) Close() error {
+	report.writer.Flush()
+	e := report.writer.Error()
+	if e != nil {
+		report.file.Close()
+		return e
+	}
+	return report.file.Close()
+}
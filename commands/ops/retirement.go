@@ -0,0 +1,84 @@
+package ops
+
+import "github.com/statecrafthq/borg/utils"
+
+// Retirement tracking fields, see utils.TrackingFields
+const (
+	FieldFirstSeen     = utils.TrackingFirstSeen
+	FieldLastSeen      = utils.TrackingLastSeen
+	FieldRetiredAt     = utils.TrackingRetiredAt
+	FieldRetiredReason = utils.TrackingRetiredReason
+	FieldMissingCount  = utils.TrackingMissingCount
+)
+
+// Reasons of retirement
+const (
+	RetiredMissing = "missing"
+	RetiredSource  = "source"
+)
+
+// DateFormat is a format of retirement tracking dates
+const DateFormat = "2006-01-02"
+
+func missingCount(row map[string]interface{}) int {
+	switch v := row[FieldMissingCount].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
+
+// TrackPresent updates tracking fields of a record that is present in latest version. Previous
+// version could be nil for new records.
+func TrackPresent(row map[string]interface{}, previous map[string]interface{}, date string) {
+	row[FieldFirstSeen] = date
+	if previous != nil {
+		if v, ok := previous[FieldFirstSeen].(string); ok {
+			row[FieldFirstSeen] = v
+		}
+	}
+	row[FieldLastSeen] = date
+	delete(row, FieldMissingCount)
+
+	if isRetired(row) {
+		// Retired by driver, keep original date if it was already retired
+		if previous != nil && isRetired(previous) {
+			if v, ok := previous[FieldRetiredAt]; ok {
+				row[FieldRetiredAt] = v
+				if r, ok := previous[FieldRetiredReason]; ok {
+					row[FieldRetiredReason] = r
+				}
+				return
+			}
+		}
+		row[FieldRetiredAt] = date
+		row[FieldRetiredReason] = RetiredSource
+	} else {
+		delete(row, FieldRetiredAt)
+		delete(row, FieldRetiredReason)
+	}
+}
+
+// TrackMissing updates record that is missing from latest version. Record is retired only after it is
+// missing from retireAfter consecutive versions.
+func TrackMissing(row map[string]interface{}, date string, retireAfter int) {
+	if isRetired(row) {
+		if _, ok := row[FieldRetiredAt]; !ok {
+			row[FieldRetiredAt] = date
+			row[FieldRetiredReason] = RetiredMissing
+		}
+		delete(row, FieldMissingCount)
+		return
+	}
+	count := missingCount(row) + 1
+	if count >= retireAfter {
+		row["retired"] = true
+		row[FieldRetiredAt] = date
+		row[FieldRetiredReason] = RetiredMissing
+		delete(row, FieldMissingCount)
+	} else {
+		row[FieldMissingCount] = count
+	}
+}
@@ -0,0 +1,60 @@
+package ops
+
+import "testing"
+
+func TestTrackPresent(t *testing.T) {
+	row := map[string]interface{}{"id": "1"}
+	TrackPresent(row, nil, "2018-01-01")
+	if row[FieldFirstSeen] != "2018-01-01" || row[FieldLastSeen] != "2018-01-01" {
+		t.Errorf("Unexpected tracking fields %v", row)
+	}
+
+	latest := map[string]interface{}{"id": "1", "retired": true}
+	TrackPresent(latest, row, "2018-02-01")
+	if latest[FieldFirstSeen] != "2018-01-01" || latest[FieldLastSeen] != "2018-02-01" {
+		t.Errorf("Unexpected tracking fields %v", latest)
+	}
+	if latest[FieldRetiredAt] != "2018-02-01" || latest[FieldRetiredReason] != RetiredSource {
+		t.Errorf("Expected record retired by source, got %v", latest)
+	}
+
+	// Retirement date is kept
+	next := map[string]interface{}{"id": "1", "retired": true}
+	TrackPresent(next, latest, "2018-03-01")
+	if next[FieldRetiredAt] != "2018-02-01" {
+		t.Errorf("Expected retirement date to be kept, got %v", next)
+	}
+
+	// Restored record
+	restored := map[string]interface{}{"id": "1", "retired": false}
+	TrackPresent(restored, next, "2018-04-01")
+	if _, ok := restored[FieldRetiredAt]; ok {
+		t.Errorf("Expected retirement to be cleared, got %v", restored)
+	}
+}
+
+func TestTrackMissing(t *testing.T) {
+	row := map[string]interface{}{"id": "1"}
+	TrackMissing(row, "2018-01-01", 3)
+	TrackMissing(row, "2018-02-01", 3)
+	if isRetired(row) || missingCount(row) != 2 {
+		t.Errorf("Record should not be retired yet: %v", row)
+	}
+	TrackMissing(row, "2018-03-01", 3)
+	if !isRetired(row) || row[FieldRetiredAt] != "2018-03-01" || row[FieldRetiredReason] != RetiredMissing {
+		t.Errorf("Record should be retired: %v", row)
+	}
+	TrackMissing(row, "2018-04-01", 3)
+	if row[FieldRetiredAt] != "2018-03-01" {
+		t.Errorf("Retirement date should be kept: %v", row)
+	}
+
+	// Appearing again resets counter
+	row = map[string]interface{}{"id": "1"}
+	TrackMissing(row, "2018-01-01", 2)
+	latest := map[string]interface{}{"id": "1"}
+	TrackPresent(latest, row, "2018-02-01")
+	if _, ok := latest[FieldMissingCount]; ok {
+		t.Errorf("Missing counter should be reset: %v", latest)
+	}
+}
@@ -0,0 +1,306 @@
+package ops
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// ExtrasTypeMixed is a type of keys that have values of different types
+const ExtrasTypeMixed = "mixed"
+
+// Maximum number of distinct values that are tracked for each key
+const schemaMaxDistinct = 1000
+
+// ValueCount is a number of records with specific value
+type ValueCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// SchemaKey describes single extras key of a dataset
+type SchemaKey struct {
+	Key       string         `json:"key"`
+	Type      string         `json:"type"`
+	Types     map[string]int `json:"types,omitempty"`
+	Count     int            `json:"count"`
+	FillRate  float64        `json:"fill_rate"`
+	Min       *float64       `json:"min,omitempty"`
+	Max       *float64       `json:"max,omitempty"`
+	Mean      *float64       `json:"mean,omitempty"`
+	Quantiles *Quantiles     `json:"quantiles,omitempty"`
+	Distinct  int            `json:"distinct,omitempty"`
+	Top       []ValueCount   `json:"top,omitempty"`
+}
+
+// Schema describes extras of a dataset
+type Schema struct {
+	Records int         `json:"records"`
+	Keys    []SchemaKey `json:"keys"`
+}
+
+type schemaKeyStats struct {
+	types     map[string]int
+	count     int
+	numbers   int
+	sum       float64
+	sample    *Sample
+	min       float64
+	max       float64
+	values    map[string]int
+	truncated bool
+}
+
+// SchemaBuilder collects schema of extras record by record
+type SchemaBuilder struct {
+	records int
+	keys    map[string]*schemaKeyStats
+}
+
+// NewSchemaBuilder creates empty builder
+func NewSchemaBuilder() *SchemaBuilder {
+	return &SchemaBuilder{keys: make(map[string]*schemaKeyStats)}
+}
+
+func (builder *SchemaBuilder) stats(key string, t string) *schemaKeyStats {
+	s, ok := builder.keys[key]
+	if !ok {
+		s = &schemaKeyStats{types: make(map[string]int), values: make(map[string]int), sample: NewSample(), min: math.MaxFloat64, max: -math.MaxFloat64}
+		builder.keys[key] = s
+	}
+	s.types[t]++
+	s.count++
+	return s
+}
+
+func (s *schemaKeyStats) addNumber(v float64) {
+	s.numbers++
+	s.sum += v
+	s.sample.Add(v)
+	s.min = math.Min(s.min, v)
+	s.max = math.Max(s.max, v)
+}
+
+func (s *schemaKeyStats) addValue(v string) {
+	if _, ok := s.values[v]; !ok && len(s.values) >= schemaMaxDistinct {
+		s.truncated = true
+		return
+	}
+	s.values[v]++
+}
+
+// Add appends extras of a record
+func (builder *SchemaBuilder) Add(extras *Extras) {
+	builder.records++
+	for _, v := range extras.Strings {
+		builder.stats(v.Key, ExtrasTypeString).addValue(v.Value)
+	}
+	for _, v := range extras.Enums {
+		s := builder.stats(v.Key, ExtrasTypeEnum)
+		for _, e := range v.Value {
+			s.addValue(e)
+		}
+	}
+	for _, v := range extras.Floats {
+		builder.stats(v.Key, ExtrasTypeFloat).addNumber(v.Value)
+	}
+	for _, v := range extras.Ints {
+		builder.stats(v.Key, ExtrasTypeInt).addNumber(float64(v.Value))
+	}
+}
+
+// Build creates schema with topN most frequent values of string and enum keys
+func (builder *SchemaBuilder) Build(topN int) *Schema {
+	res := &Schema{Records: builder.records, Keys: make([]SchemaKey, 0)}
+	for key, s := range builder.keys {
+		k := SchemaKey{Key: key, Count: s.count}
+		if builder.records > 0 {
+			k.FillRate = float64(s.count) / float64(builder.records)
+		}
+		if len(s.types) == 1 {
+			for t := range s.types {
+				k.Type = t
+			}
+		} else {
+			k.Type = ExtrasTypeMixed
+			k.Types = s.types
+		}
+		if s.numbers > 0 {
+			min := s.min
+			max := s.max
+			mean := s.sum / float64(s.numbers)
+			k.Min = &min
+			k.Max = &max
+			k.Mean = &mean
+			k.Quantiles = s.sample.Quantiles()
+		}
+		if len(s.values) > 0 {
+			k.Distinct = len(s.values)
+			top := make([]ValueCount, 0)
+			for v, c := range s.values {
+				top = append(top, ValueCount{Value: v, Count: c})
+			}
+			sort.Slice(top, func(i, j int) bool {
+				if top[i].Count == top[j].Count {
+					return top[i].Value < top[j].Value
+				}
+				return top[i].Count > top[j].Count
+			})
+			if len(top) > topN {
+				top = top[:topN]
+			}
+			k.Top = top
+		}
+		res.Keys = append(res.Keys, k)
+	}
+	sort.Slice(res.Keys, func(i, j int) bool { return res.Keys[i].Key < res.Keys[j].Key })
+	return res
+}
+
+// InferSchema builds schema of OLS file
+func InferSchema(src string, topN int) (*Schema, error) {
+	builder := NewSchemaBuilder()
+	e := RecordReader(src, func(row map[string]interface{}) error {
+		extras, e := LoadExtras(row["extras"])
+		if e != nil {
+			return e
+		}
+		builder.Add(extras)
+		return nil
+	})
+	if e != nil {
+		return nil, e
+	}
+	return builder.Build(topN), nil
+}
+
+// Kinds of schema changes
+const (
+	SchemaKeyAdded       = "added"
+	SchemaKeyRemoved     = "removed"
+	SchemaKeyRetyped     = "retyped"
+	SchemaKeyFillDropped = "fill_dropped"
+)
+
+// SchemaChange is a difference between two versions of a schema
+type SchemaChange struct {
+	Key  string
+	Kind string
+	Old  string
+	New  string
+}
+
+// Breaking checks if change should fail schema check
+func (change SchemaChange) Breaking() bool {
+	return change.Kind == SchemaKeyRemoved || change.Kind == SchemaKeyRetyped
+}
+
+// DiffSchemas compares two versions of a schema. Drops of fill rate bigger than maxFillDrop are reported too.
+func DiffSchemas(previous *Schema, latest *Schema, maxFillDrop float64) []SchemaChange {
+	res := make([]SchemaChange, 0)
+	latestKeys := make(map[string]SchemaKey)
+	for _, k := range latest.Keys {
+		latestKeys[k.Key] = k
+	}
+	previousKeys := make(map[string]bool)
+	for _, p := range previous.Keys {
+		previousKeys[p.Key] = true
+		l, ok := latestKeys[p.Key]
+		if !ok {
+			res = append(res, SchemaChange{Key: p.Key, Kind: SchemaKeyRemoved, Old: p.Type})
+			continue
+		}
+		if l.Type != p.Type {
+			res = append(res, SchemaChange{Key: p.Key, Kind: SchemaKeyRetyped, Old: p.Type, New: l.Type})
+			continue
+		}
+		if p.FillRate-l.FillRate > maxFillDrop {
+			res = append(res, SchemaChange{
+				Key:  p.Key,
+				Kind: SchemaKeyFillDropped,
+				Old:  formatPercent(p.FillRate),
+				New:  formatPercent(l.FillRate),
+			})
+		}
+	}
+	for _, l := range latest.Keys {
+		if !previousKeys[l.Key] {
+			res = append(res, SchemaChange{Key: l.Key, Kind: SchemaKeyAdded, New: l.Type})
+		}
+	}
+	return res
+}
+
+func formatPercent(v float64) string {
+	return strconv.FormatFloat(v*100, 'f', 1, 64) + "%"
+}
+
+// LoadSchema reads schema from JSON file
+func LoadSchema(src string) (*Schema, error) {
+	data, e := ioutil.ReadFile(src)
+	if e != nil {
+		return nil, e
+	}
+	var res Schema
+	e = json.Unmarshal(data, &res)
+	if e != nil {
+		return nil, e
+	}
+	return &res, nil
+}
+
+// WriteSchema writes schema to JSON file
+func WriteSchema(dst string, schema *Schema) error {
+	data, e := json.MarshalIndent(schema, "", "  ")
+	if e != nil {
+		return e
+	}
+	return ioutil.WriteFile(dst, data, 0644)
+}
+
+// ReadRemoteSchema reads schema from a bucket, returns nil if it doesn't exist
+func ReadRemoteSchema(fullPath string) (*Schema, error) {
+	bucket, err := CreateBucket()
+	if err != nil {
+		return nil, err
+	}
+	reader, err := bucket.Object(fullPath).NewReader(context.Background())
+	if err != nil {
+		if err.Error() != "storage: object doesn't exist" {
+			return nil, err
+		}
+		return nil, nil
+	}
+	defer reader.Close()
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	var res Schema
+	err = json.Unmarshal(data, &res)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// WriteRemoteSchema uploads schema to a bucket
+func WriteRemoteSchema(fullPath string, schema *Schema) error {
+	bucket, err := CreateBucket()
+	if err != nil {
+		return err
+	}
+	writer := bucket.Object(fullPath).NewWriter(context.Background())
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(data)
+	if err != nil {
+		return err
+	}
+	return writer.Close()
+}
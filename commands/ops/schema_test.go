@@ -0,0 +1,68 @@
+package ops
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchema(t *testing.T) {
+	builder := NewSchemaBuilder()
+	a := NewExtras()
+	a.AppendString("owner", "city")
+	a.AppendFloat("area", 10)
+	a.AppendEnum("zoning", []string{"R1", "R2"})
+	builder.Add(&a)
+	b := NewExtras()
+	b.AppendString("owner", "city")
+	b.AppendInt("area", 20)
+	builder.Add(&b)
+	c := NewExtras()
+	c.AppendString("owner", "private")
+	builder.Add(&c)
+
+	schema := builder.Build(1)
+	assert.Equal(t, 3, schema.Records)
+	assert.Equal(t, 3, len(schema.Keys))
+
+	area := schema.Keys[0]
+	assert.Equal(t, "area", area.Key)
+	assert.Equal(t, ExtrasTypeMixed, area.Type)
+	assert.InDelta(t, 0.666, area.FillRate, 0.001)
+	assert.Equal(t, 10.0, *area.Min)
+	assert.Equal(t, 20.0, *area.Max)
+	assert.Equal(t, 15.0, *area.Mean)
+
+	owner := schema.Keys[1]
+	assert.Equal(t, ExtrasTypeString, owner.Type)
+	assert.Equal(t, 1.0, owner.FillRate)
+	assert.Equal(t, 2, owner.Distinct)
+	assert.Equal(t, []ValueCount{{Value: "city", Count: 2}}, owner.Top)
+
+	assert.Equal(t, ExtrasTypeEnum, schema.Keys[2].Type)
+}
+
+func TestDiffSchemas(t *testing.T) {
+	previous := &Schema{Records: 10, Keys: []SchemaKey{
+		{Key: "area", Type: ExtrasTypeFloat, FillRate: 1},
+		{Key: "owner", Type: ExtrasTypeString, FillRate: 1},
+		{Key: "zoning", Type: ExtrasTypeEnum, FillRate: 0.9},
+		{Key: "year", Type: ExtrasTypeInt, FillRate: 1},
+	}}
+	latest := &Schema{Records: 10, Keys: []SchemaKey{
+		{Key: "area", Type: ExtrasTypeFloat, FillRate: 1},
+		{Key: "owner", Type: ExtrasTypeString, FillRate: 0.5},
+		{Key: "zoning", Type: ExtrasTypeString, FillRate: 0.9},
+		{Key: "owner_name", Type: ExtrasTypeString, FillRate: 0.5},
+	}}
+	changes := DiffSchemas(previous, latest, 0.2)
+	assert.Equal(t, []SchemaChange{
+		{Key: "owner", Kind: SchemaKeyFillDropped, Old: "100.0%", New: "50.0%"},
+		{Key: "zoning", Kind: SchemaKeyRetyped, Old: ExtrasTypeEnum, New: ExtrasTypeString},
+		{Key: "year", Kind: SchemaKeyRemoved, Old: ExtrasTypeInt},
+		{Key: "owner_name", Kind: SchemaKeyAdded, New: ExtrasTypeString},
+	}, changes)
+	assert.False(t, changes[0].Breaking())
+	assert.True(t, changes[1].Breaking())
+	assert.True(t, changes[2].Breaking())
+}
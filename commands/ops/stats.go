@@ -0,0 +1,182 @@
+package ops
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/statecrafthq/borg/geometry"
+	"github.com/statecrafthq/borg/utils"
+)
+
+// Maximum number of values that are kept for quantiles calculation
+const sampleSize = 100000
+
+// Sample is a reservoir sample of numbers
+type Sample struct {
+	values []float64
+	seen   int
+	random *rand.Rand
+}
+
+// NewSample creates empty sample
+func NewSample() *Sample {
+	return &Sample{values: make([]float64, 0), random: rand.New(rand.NewSource(1))}
+}
+
+// Add appends value to a sample
+func (sample *Sample) Add(v float64) {
+	sample.seen++
+	if len(sample.values) < sampleSize {
+		sample.values = append(sample.values, v)
+		return
+	}
+	i := sample.random.Intn(sample.seen)
+	if i < sampleSize {
+		sample.values[i] = v
+	}
+}
+
+// Quantiles of a distribution
+type Quantiles struct {
+	P5  float64 `json:"p5"`
+	P25 float64 `json:"p25"`
+	P50 float64 `json:"p50"`
+	P75 float64 `json:"p75"`
+	P95 float64 `json:"p95"`
+}
+
+// Quantiles calculates quantiles of sampled values
+func (sample *Sample) Quantiles() *Quantiles {
+	if len(sample.values) == 0 {
+		return nil
+	}
+	sorted := make([]float64, len(sample.values))
+	copy(sorted, sample.values)
+	sort.Float64s(sorted)
+	q := func(p float64) float64 {
+		return sorted[int(math.Round(p*float64(len(sorted)-1)))]
+	}
+	return &Quantiles{P5: q(0.05), P25: q(0.25), P50: q(0.5), P75: q(0.75), P95: q(0.95)}
+}
+
+// HistogramBucket is a number of records with values up to Max
+type HistogramBucket struct {
+	Max   int `json:"max"`
+	Count int `json:"count"`
+}
+
+// GeometryStats describes geometries of a dataset
+type GeometryStats struct {
+	Count         int                 `json:"count"`
+	Multipolygons int                 `json:"multipolygons"`
+	WithHoles     int                 `json:"with_holes"`
+	Vertices      []HistogramBucket   `json:"vertices"`
+	AreaMin       float64             `json:"area_min"`
+	AreaMax       float64             `json:"area_max"`
+	AreaMean      float64             `json:"area_mean"`
+	Area          *Quantiles          `json:"area"`
+	Bounds        *geometry.BoundsGeo `json:"bounds"`
+}
+
+// DatasetStats is a profile of OLS dataset
+type DatasetStats struct {
+	Records  int           `json:"records"`
+	Retired  int           `json:"retired"`
+	Geometry GeometryStats `json:"geometry"`
+	Extras   []SchemaKey   `json:"extras"`
+}
+
+// StatsBuilder collects stats record by record
+type StatsBuilder struct {
+	stats    DatasetStats
+	areas    *Sample
+	areaSum  float64
+	vertices []HistogramBucket
+	extras   *SchemaBuilder
+}
+
+// NewStatsBuilder creates empty builder
+func NewStatsBuilder() *StatsBuilder {
+	buckets := make([]HistogramBucket, 0)
+	for _, m := range []int{4, 8, 16, 32, 64, 128, 256, 512, math.MaxInt32} {
+		buckets = append(buckets, HistogramBucket{Max: m})
+	}
+	return &StatsBuilder{areas: NewSample(), vertices: buckets, extras: NewSchemaBuilder()}
+}
+
+// Add appends record
+func (builder *StatsBuilder) Add(row map[string]interface{}) error {
+	s := &builder.stats
+	s.Records++
+	if r, ok := row["retired"].(bool); ok && r {
+		s.Retired++
+	}
+	if g, ok := row["geometry"]; ok {
+		coords := utils.ParseFloat4(g.([]interface{}))
+		geo := geometry.NewGeoMultipolygon(coords)
+		s.Geometry.Count++
+		if len(coords) > 1 {
+			s.Geometry.Multipolygons++
+		}
+		vertices := 0
+		for _, poly := range coords {
+			if len(poly) > 1 {
+				s.Geometry.WithHoles++
+			}
+			for _, ring := range poly {
+				vertices += len(ring)
+			}
+		}
+		for i := range builder.vertices {
+			if vertices <= builder.vertices[i].Max {
+				builder.vertices[i].Count++
+				break
+			}
+		}
+
+		area := math.Abs(geo.Area())
+		builder.areas.Add(area)
+		builder.areaSum += area
+		if s.Geometry.Count == 1 {
+			s.Geometry.AreaMin = area
+			s.Geometry.AreaMax = area
+		} else {
+			s.Geometry.AreaMin = math.Min(s.Geometry.AreaMin, area)
+			s.Geometry.AreaMax = math.Max(s.Geometry.AreaMax, area)
+		}
+
+		b := geo.Bounds()
+		if s.Geometry.Bounds == nil {
+			s.Geometry.Bounds = &b
+		} else {
+			s.Geometry.Bounds.MinLatitude = math.Min(s.Geometry.Bounds.MinLatitude, b.MinLatitude)
+			s.Geometry.Bounds.MinLongitude = math.Min(s.Geometry.Bounds.MinLongitude, b.MinLongitude)
+			s.Geometry.Bounds.MaxLatitude = math.Max(s.Geometry.Bounds.MaxLatitude, b.MaxLatitude)
+			s.Geometry.Bounds.MaxLongitude = math.Max(s.Geometry.Bounds.MaxLongitude, b.MaxLongitude)
+		}
+	}
+	extras, e := LoadExtras(row["extras"])
+	if e != nil {
+		return e
+	}
+	builder.extras.Add(extras)
+	return nil
+}
+
+// Build creates stats with topN most frequent values of string and enum keys
+func (builder *StatsBuilder) Build(topN int) *DatasetStats {
+	res := builder.stats
+	res.Geometry.Vertices = make([]HistogramBucket, 0)
+	for _, b := range builder.vertices {
+		if b.Count > 0 {
+			res.Geometry.Vertices = append(res.Geometry.Vertices, b)
+		}
+	}
+	if res.Geometry.Count > 0 {
+		res.Geometry.AreaMean = builder.areaSum / float64(res.Geometry.Count)
+	}
+	res.Geometry.Area = builder.areas.Quantiles()
+	res.Extras = builder.extras.Build(topN).Keys
+	return &res
+}
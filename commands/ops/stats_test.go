@@ -0,0 +1,37 @@
+package ops
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampleQuantiles(t *testing.T) {
+	sample := NewSample()
+	assert.Nil(t, sample.Quantiles())
+	for i := 0; i <= 100; i++ {
+		sample.Add(float64(i))
+	}
+	q := sample.Quantiles()
+	assert.Equal(t, 5.0, q.P5)
+	assert.Equal(t, 50.0, q.P50)
+	assert.Equal(t, 95.0, q.P95)
+}
+
+func TestStatsBuilder(t *testing.T) {
+	builder := NewStatsBuilder()
+	assert.NoError(t, builder.Add(parseRow(t, `{"id":"1","geometry":[[[[-74.0,40.7],[-73.999,40.7],[-73.999,40.7009],[-74.0,40.7009],[-74.0,40.7]]]],"extras":{"ints":[{"key":"year","value":1990}]}}`)))
+	assert.NoError(t, builder.Add(parseRow(t, `{"id":"2","retired":true,"extras":{"ints":[{"key":"year","value":2000}]}}`)))
+	assert.NoError(t, builder.Add(parseRow(t, `{"id":"3","geometry":[[[[-74.0,40.7],[-73.998,40.7],[-73.998,40.7009],[-74.0,40.7009],[-74.0,40.7]]],[[[-75.0,41.7],[-74.998,41.7],[-74.998,41.7009],[-75.0,41.7]]]]}`)))
+	stats := builder.Build(5)
+	assert.Equal(t, 3, stats.Records)
+	assert.Equal(t, 1, stats.Retired)
+	assert.Equal(t, 2, stats.Geometry.Count)
+	assert.Equal(t, 1, stats.Geometry.Multipolygons)
+	assert.Equal(t, []HistogramBucket{{Max: 8, Count: 1}, {Max: 16, Count: 1}}, stats.Geometry.Vertices)
+	assert.Equal(t, -75.0, stats.Geometry.Bounds.MinLongitude)
+	assert.Equal(t, 41.7009, stats.Geometry.Bounds.MaxLatitude)
+	assert.True(t, stats.Geometry.AreaMax > stats.Geometry.AreaMin)
+	assert.Equal(t, 1, len(stats.Extras))
+	assert.Equal(t, 1995.0, *stats.Extras[0].Mean)
+}
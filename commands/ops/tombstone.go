@@ -0,0 +1,20 @@
+package ops
+
+// FieldRemoved marks tombstone records: records that were removed from a dataset. Tombstones are
+// written by diff and are sent to the server with retire mutation while importing.
+const FieldRemoved = "$removed"
+
+// NewTombstone creates tombstone record for a removed record
+func NewTombstone(row map[string]interface{}) map[string]interface{} {
+	res := map[string]interface{}{"id": row["id"], FieldRemoved: true}
+	if displayID, ok := row["displayId"]; ok {
+		res["displayId"] = displayID
+	}
+	return res
+}
+
+// IsTombstone checks if record is a tombstone of a removed record
+func IsTombstone(row map[string]interface{}) bool {
+	removed, ok := row[FieldRemoved].(bool)
+	return ok && removed
+}
@@ -0,0 +1,336 @@
+package ops
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/statecrafthq/borg/geometry"
+)
+
+// Topology is a set of shapes built from shared arcs, same as in TopoJSON. Arc references
+// are indexes of arcs, negative references (^index) means reversed arc.
+type Topology struct {
+	Arcs       [][][]float64
+	Simplified [][][]float64
+	Shapes     [][][][]int
+	locked     map[int]bool
+}
+
+type vertexNeighbors struct {
+	a VertexKey
+	b VertexKey
+}
+
+func newVertexNeighbors(a VertexKey, b VertexKey) vertexNeighbors {
+	if b.X < a.X || (b.X == a.X && b.Y < a.Y) {
+		return vertexNeighbors{a: b, b: a}
+	}
+	return vertexNeighbors{a: a, b: b}
+}
+
+func openRing(ring [][]float64) [][]float64 {
+	if len(ring) > 1 && ring[0][0] == ring[len(ring)-1][0] && ring[0][1] == ring[len(ring)-1][1] {
+		return ring[:len(ring)-1]
+	}
+	return ring
+}
+
+func arcKey(arc [][]float64) string {
+	parts := make([]string, len(arc))
+	for i, p := range arc {
+		k := NewVertexKey(p)
+		parts[i] = strconv.FormatInt(k.X, 10) + "," + strconv.FormatInt(k.Y, 10)
+	}
+	return strings.Join(parts, ";")
+}
+
+func reversedArc(arc [][]float64) [][]float64 {
+	res := make([][]float64, len(arc))
+	for i := range arc {
+		res[i] = arc[len(arc)-1-i]
+	}
+	return res
+}
+
+// BuildTopology splits rings of all shapes to arcs at junctions (vertices where neighbors
+// of rings are different) and deduplicates arcs shared between shapes
+func BuildTopology(shapes [][][][][]float64) *Topology {
+
+	// Searching for junctions
+	neighbors := make(map[VertexKey]vertexNeighbors)
+	junctions := make(map[VertexKey]bool)
+	for _, shape := range shapes {
+		for _, poly := range shape {
+			for _, ring := range poly {
+				open := openRing(ring)
+				n := len(open)
+				for i := range open {
+					k := NewVertexKey(open[i])
+					nb := newVertexNeighbors(NewVertexKey(open[(i+n-1)%n]), NewVertexKey(open[(i+1)%n]))
+					if existing, ok := neighbors[k]; ok {
+						if existing != nb {
+							junctions[k] = true
+						}
+					} else {
+						neighbors[k] = nb
+					}
+				}
+			}
+		}
+	}
+
+	// Cutting rings
+	res := &Topology{Arcs: make([][][]float64, 0), Shapes: make([][][][]int, 0)}
+	arcs := make(map[string]int)
+	addArc := func(arc [][]float64) int {
+		fwd := arcKey(arc)
+		rev := arcKey(reversedArc(arc))
+		if fwd <= rev {
+			if i, ok := arcs[fwd]; ok {
+				return i
+			}
+			arcs[fwd] = len(res.Arcs)
+			res.Arcs = append(res.Arcs, arc)
+			return len(res.Arcs) - 1
+		}
+		if i, ok := arcs[rev]; ok {
+			return ^i
+		}
+		arcs[rev] = len(res.Arcs)
+		res.Arcs = append(res.Arcs, reversedArc(arc))
+		return ^(len(res.Arcs) - 1)
+	}
+	for _, shape := range shapes {
+		s := make([][][]int, 0)
+		for _, poly := range shape {
+			p := make([][]int, 0)
+			for _, ring := range poly {
+				open := openRing(ring)
+				n := len(open)
+				if n == 0 {
+					p = append(p, []int{})
+					continue
+				}
+
+				// Ring starts from a junction or from a minimal vertex
+				start := -1
+				for i := range open {
+					if junctions[NewVertexKey(open[i])] {
+						start = i
+						break
+					}
+				}
+				if start < 0 {
+					start = 0
+					for i := range open {
+						a := NewVertexKey(open[i])
+						b := NewVertexKey(open[start])
+						if a.X < b.X || (a.X == b.X && a.Y < b.Y) {
+							start = i
+						}
+					}
+				}
+
+				refs := make([]int, 0)
+				arc := [][]float64{open[start]}
+				for k := 1; k <= n; k++ {
+					pt := open[(start+k)%n]
+					arc = append(arc, pt)
+					if k == n || junctions[NewVertexKey(pt)] {
+						refs = append(refs, addArc(arc))
+						arc = [][]float64{pt}
+					}
+				}
+				p = append(p, refs)
+			}
+			s = append(s, p)
+		}
+		res.Shapes = append(res.Shapes, s)
+	}
+	res.Simplified = res.Arcs
+	return res
+}
+
+func arcIndex(ref int) int {
+	if ref < 0 {
+		return ^ref
+	}
+	return ref
+}
+
+func (topology *Topology) ring(refs []int, arcs [][][]float64) [][]float64 {
+	res := make([][]float64, 0)
+	for _, ref := range refs {
+		arc := arcs[arcIndex(ref)]
+		if ref < 0 {
+			arc = reversedArc(arc)
+		}
+		if len(res) > 0 {
+			arc = arc[1:]
+		}
+		res = append(res, arc...)
+	}
+	return res
+}
+
+// Shape rebuilds simplified shape from arcs
+func (topology *Topology) Shape(index int) [][][][]float64 {
+	res := make([][][][]float64, 0)
+	for _, poly := range topology.Shapes[index] {
+		p := make([][][]float64, 0)
+		for _, refs := range poly {
+			p = append(p, topology.ring(refs, topology.Simplified))
+		}
+		res = append(res, p)
+	}
+	return res
+}
+
+func simplifyArc(arc [][]float64, tolerance float64, proj *geometry.Projection) [][]float64 {
+	if len(arc) < 3 {
+		return arc
+	}
+	points := make([]geometry.Point2D, len(arc))
+	for i, p := range arc {
+		points[i] = geometry.PointGeo{Longitude: p[0], Latitude: p[1]}.Project(proj)
+	}
+	keep := make([]bool, len(arc))
+	keep[0] = true
+	keep[len(arc)-1] = true
+	closed := NewVertexKey(arc[0]) == NewVertexKey(arc[len(arc)-1])
+	if closed {
+		locked := make([]bool, len(arc)-1)
+		locked[0] = true
+		ringKeep := simplifyRing(points[:len(arc)-1], locked, tolerance)
+		copy(keep, ringKeep)
+	} else {
+		chain := make([]int, len(arc))
+		for i := range chain {
+			chain[i] = i
+		}
+		simplifyChain(points, chain, tolerance, keep)
+	}
+	res := make([][]float64, 0)
+	for i := range arc {
+		if keep[i] {
+			res = append(res, arc[i])
+		}
+	}
+	return res
+}
+
+func (topology *Topology) isValidPolygon(poly [][]int, proj *geometry.Projection) bool {
+	projected := geometry.Polygon2D{}
+	for r, refs := range poly {
+		open := openRing(topology.ring(refs, topology.Simplified))
+		if len(open) < 3 {
+			return false
+		}
+		points := make([]geometry.Point2D, len(open))
+		for i, p := range open {
+			points[i] = geometry.PointGeo{Longitude: p[0], Latitude: p[1]}.Project(proj)
+		}
+		if r == 0 {
+			projected.Polygon = points
+		} else {
+			projected.Holes = append(projected.Holes, points)
+		}
+	}
+	return projected.IsSimple()
+}
+
+// Lock keeps arcs of a shape as is during simplification, so neighbors are still sharing
+// same boundaries with it
+func (topology *Topology) Lock(index int) {
+	if topology.locked == nil {
+		topology.locked = make(map[int]bool)
+	}
+	for _, poly := range topology.Shapes[index] {
+		for _, refs := range poly {
+			for _, ref := range refs {
+				topology.locked[arcIndex(ref)] = true
+			}
+		}
+	}
+}
+
+// Simplify simplifies every arc once with tolerance in meters. Arcs of polygons that
+// become invalid are restored, so neighbors are still sharing same boundaries.
+func (topology *Topology) Simplify(tolerance float64, proj *geometry.Projection) {
+	topology.Simplified = make([][][]float64, len(topology.Arcs))
+	for i, arc := range topology.Arcs {
+		if topology.locked[i] {
+			topology.Simplified[i] = arc
+		} else {
+			topology.Simplified[i] = simplifyArc(arc, tolerance, proj)
+		}
+	}
+	restored := make([]bool, len(topology.Arcs))
+	for {
+		changed := false
+		for _, shape := range topology.Shapes {
+			for _, poly := range shape {
+				if topology.isValidPolygon(poly, proj) {
+					continue
+				}
+				for _, refs := range poly {
+					for _, ref := range refs {
+						i := arcIndex(ref)
+						if !restored[i] {
+							restored[i] = true
+							topology.Simplified[i] = topology.Arcs[i]
+							changed = true
+						}
+					}
+				}
+			}
+		}
+		if !changed {
+			return
+		}
+	}
+}
+
+type topoGeometry struct {
+	Type string    `json:"type"`
+	ID   string    `json:"id"`
+	Arcs [][][]int `json:"arcs"`
+}
+
+type topoObject struct {
+	Type       string         `json:"type"`
+	Geometries []topoGeometry `json:"geometries"`
+}
+
+type topoJSON struct {
+	Type    string                `json:"type"`
+	Objects map[string]topoObject `json:"objects"`
+	Arcs    [][][]float64         `json:"arcs"`
+}
+
+// WriteTopoJSON exports simplified topology as a TopoJSON file with a single "parcels" object
+func (topology *Topology) WriteTopoJSON(dst string, ids []string) error {
+	geometries := make([]topoGeometry, len(topology.Shapes))
+	for i, shape := range topology.Shapes {
+		geometries[i] = topoGeometry{Type: "MultiPolygon", ID: ids[i], Arcs: shape}
+	}
+	res := topoJSON{
+		Type:    "Topology",
+		Objects: map[string]topoObject{"parcels": {Type: "GeometryCollection", Geometries: geometries}},
+		Arcs:    topology.Simplified,
+	}
+	file, e := os.Create(dst)
+	if e != nil {
+		return e
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
+	e = json.NewEncoder(w).Encode(res)
+	if e != nil {
+		return e
+	}
+	return w.Flush()
+}
@@ -0,0 +1,54 @@
+package ops
+
+import (
+	"testing"
+
+	"github.com/statecrafthq/borg/geometry"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopology(t *testing.T) {
+	// Two parcels sharing a wiggly boundary
+	left := [][][][]float64{{{
+		{-74.0, 40.7}, {-73.999, 40.7}, {-73.999001, 40.7003}, {-73.999, 40.7006}, {-73.999, 40.7009}, {-74.0, 40.7009}, {-74.0, 40.7},
+	}}}
+	right := [][][][]float64{{{
+		{-73.999, 40.7}, {-73.998, 40.7}, {-73.998, 40.7009}, {-73.999, 40.7009}, {-73.999, 40.7006}, {-73.999001, 40.7003}, {-73.999, 40.7},
+	}}}
+	topology := BuildTopology([][][][][]float64{left, right})
+
+	// Shared boundary is a single arc
+	assert.Equal(t, 3, len(topology.Arcs))
+	assert.Equal(t, 2, len(topology.Shapes[0][0][0]))
+	assert.Equal(t, 2, len(topology.Shapes[1][0][0]))
+
+	// Without simplification shapes are restored
+	assert.Equal(t, 7, len(topology.Shape(0)[0][0]))
+
+	topology.Simplify(1, geometry.NewProjection(geometry.PointGeo{Longitude: -73.999, Latitude: 40.7005}))
+	l := topology.Shape(0)[0][0]
+	r := topology.Shape(1)[0][0]
+	assert.Equal(t, 5, len(l))
+	assert.Equal(t, 5, len(r))
+	assert.Equal(t, l[0], l[len(l)-1])
+
+	// Both parcels have same vertices on shared boundary
+	keys := make(map[VertexKey]bool)
+	for _, p := range l {
+		keys[NewVertexKey(p)] = true
+	}
+	shared := make(map[VertexKey]bool)
+	for _, q := range r {
+		if keys[NewVertexKey(q)] {
+			shared[NewVertexKey(q)] = true
+		}
+	}
+	assert.Equal(t, 2, len(shared))
+
+	// Locked shapes are kept with their boundaries
+	topology = BuildTopology([][][][][]float64{left, right})
+	topology.Lock(0)
+	topology.Simplify(1, geometry.NewProjection(geometry.PointGeo{Longitude: -73.999, Latitude: 40.7005}))
+	assert.Equal(t, 7, len(topology.Shape(0)[0][0]))
+	assert.Equal(t, 7, len(topology.Shape(1)[0][0]))
+}
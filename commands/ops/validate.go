@@ -0,0 +1,261 @@
+package ops
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+
+	"github.com/statecrafthq/borg/geometry"
+	"github.com/statecrafthq/borg/utils"
+)
+
+// Names of validation rules
+const (
+	RuleUniqueID      = "unique_id"
+	RuleGeometry      = "geometry"
+	RuleBounds        = "bounds"
+	RuleArea          = "area"
+	RuleRequired      = "required"
+	RuleAllowedValues = "allowed_values"
+)
+
+// RequiredKey is an extras key that should be present in every record
+type RequiredKey struct {
+	Key string `json:"key"`
+	// One of string, enum, float, int or number (float or int). Any type if empty.
+	Type string `json:"type"`
+}
+
+// AllowedValues is a list of allowed values of string or enum key
+type AllowedValues struct {
+	Key    string   `json:"key"`
+	Values []string `json:"values"`
+}
+
+// BoundsRange is an expected bounding box of all coordinates
+type BoundsRange struct {
+	MinLongitude float64 `json:"min_longitude"`
+	MinLatitude  float64 `json:"min_latitude"`
+	MaxLongitude float64 `json:"max_longitude"`
+	MaxLatitude  float64 `json:"max_latitude"`
+}
+
+// AreaRange is an allowed range of area in square meters, zero means no limit
+type AreaRange struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// ValidationRules is a configuration of validate command
+type ValidationRules struct {
+	UniqueIDs     bool            `json:"unique_ids"`
+	ValidGeometry bool            `json:"valid_geometry"`
+	Bounds        *BoundsRange    `json:"bounds"`
+	Area          *AreaRange      `json:"area"`
+	Required      []RequiredKey   `json:"required"`
+	AllowedValues []AllowedValues `json:"allowed_values"`
+}
+
+// Violation is a failed rule of a record
+type Violation struct {
+	ID      string
+	Rule    string
+	Message string
+}
+
+// DefaultValidationRules checks only ids and geometry
+func DefaultValidationRules() *ValidationRules {
+	return &ValidationRules{UniqueIDs: true, ValidGeometry: true}
+}
+
+// LoadValidationRules reads rules from JSON file
+func LoadValidationRules(src string) (*ValidationRules, error) {
+	data, e := ioutil.ReadFile(src)
+	if e != nil {
+		return nil, e
+	}
+	var res ValidationRules
+	e = json.Unmarshal(data, &res)
+	if e != nil {
+		return nil, e
+	}
+	for _, r := range res.Required {
+		if r.Key == "" {
+			return nil, errors.New("Required key should have a name")
+		}
+		switch r.Type {
+		case "", ExtrasTypeString, ExtrasTypeEnum, ExtrasTypeFloat, ExtrasTypeInt, "number":
+		default:
+			return nil, errors.New("Unknown type '" + r.Type + "' of key " + r.Key)
+		}
+	}
+	for _, a := range res.AllowedValues {
+		if a.Key == "" {
+			return nil, errors.New("Allowed values should have a key")
+		}
+	}
+	return &res, nil
+}
+
+// Validator checks records against rules. Not safe for concurrent use.
+type Validator struct {
+	rules *ValidationRules
+	ids   map[string]bool
+}
+
+// NewValidator creates validator for rules
+func NewValidator(rules *ValidationRules) *Validator {
+	return &Validator{rules: rules, ids: make(map[string]bool)}
+}
+
+// parseGeometry is same as utils.ParseFloat4, but returns error instead of panic on malformed or empty
+// geometry, so it is safe to project the result
+func parseGeometry(src interface{}) ([][][][]float64, error) {
+	polys, ok := src.([]interface{})
+	if !ok {
+		return nil, errors.New("geometry is not an array")
+	}
+	if len(polys) == 0 {
+		return nil, errors.New("geometry is empty")
+	}
+	res := make([][][][]float64, 0)
+	for i, p := range polys {
+		rings, ok := p.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("polygon %d is not an array", i)
+		}
+		if len(rings) == 0 {
+			return nil, fmt.Errorf("polygon %d has no rings", i)
+		}
+		poly := make([][][]float64, 0)
+		for _, r := range rings {
+			points, ok := r.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("ring of polygon %d is not an array", i)
+			}
+			if len(points) < 4 {
+				return nil, fmt.Errorf("ring of polygon %d has less than 4 points", i)
+			}
+			ring := make([][]float64, 0)
+			for _, pt := range points {
+				coord, ok := pt.([]interface{})
+				if !ok || len(coord) < 2 {
+					return nil, fmt.Errorf("point of polygon %d is not a coordinate pair", i)
+				}
+				x, okX := coord[0].(float64)
+				y, okY := coord[1].(float64)
+				if !okX || !okY {
+					return nil, fmt.Errorf("point of polygon %d has non-numeric coordinates", i)
+				}
+				ring = append(ring, []float64{x, y})
+			}
+			poly = append(poly, ring)
+		}
+		res = append(res, poly)
+	}
+	return res, nil
+}
+
+func (v *Validator) validateGeometry(id string, coords [][][][]float64) []Violation {
+	res := make([]Violation, 0)
+	if v.rules.ValidGeometry {
+		e := utils.ValidateGeometry(coords)
+		if e != nil {
+			return append(res, Violation{ID: id, Rule: RuleGeometry, Message: e.Error()})
+		}
+		geo := geometry.NewGeoMultipolygon(coords)
+		projected := geo.Project(geometry.NewProjection(geo.Center()))
+		for i, p := range projected.Polygons {
+			if len(p.Polygon) < 3 {
+				res = append(res, Violation{ID: id, Rule: RuleGeometry, Message: fmt.Sprintf("polygon %d has less than 3 points", i)})
+			} else if !p.IsSimple() {
+				res = append(res, Violation{ID: id, Rule: RuleGeometry, Message: fmt.Sprintf("polygon %d is self intersecting", i)})
+			}
+		}
+	}
+	if v.rules.Bounds != nil {
+		b := v.rules.Bounds
+	outer:
+		for _, poly := range coords {
+			for _, ring := range poly {
+				for _, p := range ring {
+					if p[0] < b.MinLongitude || p[0] > b.MaxLongitude || p[1] < b.MinLatitude || p[1] > b.MaxLatitude {
+						res = append(res, Violation{ID: id, Rule: RuleBounds, Message: fmt.Sprintf("point (%f,%f) is out of bounds", p[0], p[1])})
+						break outer
+					}
+				}
+			}
+		}
+	}
+	if v.rules.Area != nil {
+		area := math.Abs(geometry.NewGeoMultipolygon(coords).Area())
+		if area < v.rules.Area.Min || (v.rules.Area.Max > 0 && area > v.rules.Area.Max) {
+			res = append(res, Violation{ID: id, Rule: RuleArea, Message: fmt.Sprintf("area %.2f is out of range", area)})
+		}
+	}
+	return res
+}
+
+func (v *Validator) validateExtras(id string, extras *Extras) []Violation {
+	res := make([]Violation, 0)
+	for _, r := range v.rules.Required {
+		t := extras.KeyType(r.Key)
+		if t == "" {
+			res = append(res, Violation{ID: id, Rule: RuleRequired, Message: "missing key " + r.Key})
+		} else if r.Type != "" && t != r.Type && !(r.Type == "number" && (t == ExtrasTypeFloat || t == ExtrasTypeInt)) {
+			res = append(res, Violation{ID: id, Rule: RuleRequired, Message: "key " + r.Key + " should be " + r.Type + ", got " + t})
+		}
+	}
+	for _, a := range v.rules.AllowedValues {
+		values := make([]string, 0)
+		if ok, enum := extras.GetEnum(a.Key); ok {
+			values = enum
+		} else if extras.KeyType(a.Key) == ExtrasTypeString {
+			_, s := extras.GetValue(a.Key)
+			values = append(values, s)
+		}
+		for _, value := range values {
+			allowed := false
+			for _, av := range a.Values {
+				if av == value {
+					allowed = true
+					break
+				}
+			}
+			if !allowed {
+				res = append(res, Violation{ID: id, Rule: RuleAllowedValues, Message: "value '" + value + "' is not allowed for " + a.Key})
+			}
+		}
+	}
+	return res
+}
+
+// Validate checks single record
+func (v *Validator) Validate(row map[string]interface{}) []Violation {
+	res := make([]Violation, 0)
+	id, ok := row["id"].(string)
+	if !ok || id == "" {
+		return append(res, Violation{Rule: RuleUniqueID, Message: "record without id"})
+	}
+	if v.rules.UniqueIDs {
+		if v.ids[id] {
+			res = append(res, Violation{ID: id, Rule: RuleUniqueID, Message: "duplicate id"})
+		}
+		v.ids[id] = true
+	}
+	if g, ok := row["geometry"]; ok {
+		coords, e := parseGeometry(g)
+		if e != nil {
+			res = append(res, Violation{ID: id, Rule: RuleGeometry, Message: e.Error()})
+		} else {
+			res = append(res, v.validateGeometry(id, coords)...)
+		}
+	}
+	extras, e := LoadExtras(row["extras"])
+	if e != nil {
+		return append(res, Violation{ID: id, Rule: RuleRequired, Message: "invalid extras: " + e.Error()})
+	}
+	return append(res, v.validateExtras(id, extras)...)
+}
@@ -0,0 +1,72 @@
+package ops
+
+import (
+	"encoding/json"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func parseRow(t *testing.T, src string) map[string]interface{} {
+	var res map[string]interface{}
+	e := json.Unmarshal([]byte(src), &res)
+	if e != nil {
+		t.Fatal(e)
+	}
+	return res
+}
+
+func rules(violations []Violation) []string {
+	res := make([]string, 0)
+	for _, v := range violations {
+		res = append(res, v.Rule)
+	}
+	return res
+}
+
+func TestValidator(t *testing.T) {
+	path := writeCatalog(t, `{
+		"unique_ids": true,
+		"valid_geometry": true,
+		"bounds": {"min_longitude": -75, "min_latitude": 40, "max_longitude": -73, "max_latitude": 41},
+		"area": {"min": 10, "max": 100000},
+		"required": [{"key": "owner", "type": "string"}, {"key": "size", "type": "number"}],
+		"allowed_values": [{"key": "zoning", "values": ["R1", "R2"]}]
+	}`)
+	defer os.Remove(path)
+	r, e := LoadValidationRules(path)
+	assert.NoError(t, e)
+	v := NewValidator(r)
+
+	valid := `{"id":"1","geometry":[[[[-74.0,40.7],[-73.999,40.7],[-73.999,40.7009],[-74.0,40.7009],[-74.0,40.7]]]],
+		"extras":{"strings":[{"key":"owner","value":"city"}],"ints":[{"key":"size","value":5}],"enums":[{"key":"zoning","value":["R1"]}]}}`
+	assert.Equal(t, []string{}, rules(v.Validate(parseRow(t, valid))))
+
+	// Same record again
+	assert.Equal(t, []string{RuleUniqueID}, rules(v.Validate(parseRow(t, valid))))
+
+	invalid := `{"id":"2","geometry":[[[[-74.0,40.7],[-73.999,40.7009],[-73.999,40.7],[-74.0,40.7009],[-74.0,40.7]]]],
+		"extras":{"floats":[{"key":"owner","value":1}],"enums":[{"key":"zoning","value":["R1","C4"]}]}}`
+	assert.Equal(t, []string{RuleGeometry, RuleArea, RuleRequired, RuleRequired, RuleAllowedValues}, rules(v.Validate(parseRow(t, invalid))))
+
+	outside := `{"id":"3","geometry":[[[[-80.0,40.7],[-79.9999,40.7],[-79.9999,40.7001],[-80.0,40.7001],[-80.0,40.7]]]],
+		"extras":{"strings":[{"key":"owner","value":"city"}],"floats":[{"key":"size","value":5}]}}`
+	assert.Equal(t, []string{RuleBounds}, rules(v.Validate(parseRow(t, outside))))
+
+	tiny := `{"id":"4","geometry":[[[[-74.0,40.7],[-73.99999,40.7],[-73.99999,40.70001],[-74.0,40.70001],[-74.0,40.7]]]],
+		"extras":{"strings":[{"key":"owner","value":"city"}],"floats":[{"key":"size","value":5}]}}`
+	assert.Equal(t, []string{RuleArea}, rules(v.Validate(parseRow(t, tiny))))
+
+	// Malformed geometry is reported instead of panic
+	for i, g := range []string{`"none"`, `[1]`, `[[[[-74.0]]]]`, `[[[["a","b"]]]]`, `[]`, `[[]]`, `[[[]]]`, `[[[[-74.0,40.7],[-73.99,40.7],[-74.0,40.7]]]]`} {
+		malformed := `{"id":"malformed` + strconv.Itoa(i) + `","geometry":` + g + `,"extras":{"strings":[{"key":"owner","value":"city"}],"floats":[{"key":"size","value":5}]}}`
+		assert.Equal(t, []string{RuleGeometry}, rules(v.Validate(parseRow(t, malformed))))
+	}
+
+	bad := writeCatalog(t, `{"required": [{"key": "owner", "type": "text"}]}`)
+	defer os.Remove(bad)
+	_, e = LoadValidationRules(bad)
+	assert.Error(t, e)
+}
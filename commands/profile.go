@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"time"
+
+	"github.com/statecrafthq/borg/commands/ops"
+	"github.com/statecrafthq/borg/utils"
+	"github.com/urfave/cli"
+)
+
+// config and profile are loaded before running any command
+var config = &utils.Config{Profiles: make(map[string]*utils.Profile)}
+var profile = &utils.Profile{}
+
+// LoadProfile reads config files and selects profile from --profile flag
+func LoadProfile(c *cli.Context) error {
+	loaded, e := utils.LoadConfig(utils.ConfigPaths())
+	if e != nil {
+		return cli.NewExitError(e.Error(), 1)
+	}
+	selected, e := loaded.Profile(c.GlobalString("profile"))
+	if e != nil {
+		return cli.NewExitError(e.Error(), 1)
+	}
+	config = loaded
+	profile = selected
+	if profile.Storage.Bucket != "" {
+		ops.BucketName = profile.Storage.Bucket
+	}
+	return nil
+}
+
+// profileString returns flag value if it is set explicitly, otherwise value from profile and then flag default
+func profileString(c *cli.Context, name string, value string) string {
+	if c.IsSet(name) || value == "" {
+		return c.String(name)
+	}
+	return value
+}
+
+func profileInt(c *cli.Context, name string, value int) int {
+	if c.IsSet(name) || value == 0 {
+		return c.Int(name)
+	}
+	return value
+}
+
+func profileDuration(c *cli.Context, name string) time.Duration {
+	if !c.IsSet(name) {
+		if timeout, _ := profile.TimeoutDuration(); timeout > 0 {
+			return timeout
+		}
+	}
+	return c.Duration(name)
+}
+
+// resolveServer converts profile or built-in server name to URL
+func resolveServer(server string) string {
+	if p, ok := config.Profiles[server]; ok && p.Server != "" {
+		return p.Server
+	}
+	if server == "production" || server == "prod" {
+		return "https://api.statecrafthq.com/api"
+	} else if server == "local" {
+		return "http://localhost:9000/api"
+	}
+	return server
+}
@@ -0,0 +1,151 @@
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/statecrafthq/borg/commands/ops"
+	"github.com/statecrafthq/borg/utils"
+	"github.com/urfave/cli"
+	emoji "gopkg.in/kyokomi/emoji.v1"
+)
+
+// loadOrInferSchema reads schema from JSON file or infers it from OLS dataset
+func loadOrInferSchema(src string, topN int) (*ops.Schema, error) {
+	if filepath.Ext(src) == ".json" {
+		return ops.LoadSchema(src)
+	}
+	return ops.InferSchema(src, topN)
+}
+
+func printSchemaChanges(changes []ops.SchemaChange) int {
+	breaking := 0
+	for _, c := range changes {
+		if c.Breaking() {
+			breaking++
+		}
+		switch c.Kind {
+		case ops.SchemaKeyAdded:
+			fmt.Printf("-- Added %s (%s)\n", c.Key, c.New)
+		case ops.SchemaKeyRemoved:
+			fmt.Printf("-- Removed %s (%s)\n", c.Key, c.Old)
+		case ops.SchemaKeyRetyped:
+			fmt.Printf("-- Retyped %s: %s -> %s\n", c.Key, c.Old, c.New)
+		case ops.SchemaKeyFillDropped:
+			fmt.Printf("-- Fill rate of %s dropped: %s -> %s\n", c.Key, c.Old, c.New)
+		}
+	}
+	return breaking
+}
+
+func schemaInfer(c *cli.Context) error {
+	src := c.String("src")
+	dst := c.String("dst")
+	if src == "" {
+		return cli.NewExitError("You should provide source file", 1)
+	}
+	if dst == "" {
+		return cli.NewExitError("You should provide destination file", 1)
+	}
+	e := utils.AssumeNotExists(dst, c.Bool("force"))
+	if e != nil {
+		return e
+	}
+	schema, e := ops.InferSchema(src, c.Int("top"))
+	if e != nil {
+		return e
+	}
+	emoji.Printf(":bar_chart: Found %d keys in %d records\n", len(schema.Keys), schema.Records)
+	return ops.WriteSchema(dst, schema)
+}
+
+func schemaDiff(c *cli.Context) error {
+	previousPath := c.String("old")
+	latestPath := c.String("new")
+	if previousPath == "" || latestPath == "" {
+		return cli.NewExitError("You should provide both old and new schemas or datasets", 1)
+	}
+	previous, e := loadOrInferSchema(previousPath, c.Int("top"))
+	if e != nil {
+		return e
+	}
+	latest, e := loadOrInferSchema(latestPath, c.Int("top"))
+	if e != nil {
+		return e
+	}
+	changes := ops.DiffSchemas(previous, latest, c.Float64("max-fill-drop"))
+	if len(changes) == 0 {
+		emoji.Println(":white_check_mark: Schemas are same")
+		return nil
+	}
+	emoji.Println(":bar_chart: Schema changes:")
+	breaking := printSchemaChanges(changes)
+	if breaking > 0 {
+		return cli.NewExitError(fmt.Sprintf("%d keys were removed or retyped", breaking), 1)
+	}
+	return nil
+}
+
+func CreateSchemaCommands() []cli.Command {
+	return []cli.Command{
+		{
+			Name:  "schema",
+			Usage: "Extras schema of datasets",
+			Subcommands: []cli.Command{
+				{
+					Name:  "infer",
+					Usage: "Infer schema of OLS dataset",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "source, src",
+							Usage: "Path to dataset",
+						},
+						cli.StringFlag{
+							Name:  "dest, dst",
+							Usage: "Path to schema file",
+						},
+						cli.IntFlag{
+							Name:  "top",
+							Value: 10,
+							Usage: "Number of most frequent values for string and enum keys",
+						},
+						cli.BoolFlag{
+							Name:  "force, f",
+							Usage: "Overwrite file if exists",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return schemaInfer(c)
+					},
+				},
+				{
+					Name:  "diff",
+					Usage: "Compare schemas, fails if keys were removed or retyped",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "old, previous",
+							Usage: "Previous schema (.json) or dataset",
+						},
+						cli.StringFlag{
+							Name:  "new, latest",
+							Usage: "Latest schema (.json) or dataset",
+						},
+						cli.Float64Flag{
+							Name:  "max-fill-drop",
+							Value: 0.2,
+							Usage: "Report keys which fill rate dropped more than this value",
+						},
+						cli.IntFlag{
+							Name:  "top",
+							Value: 10,
+							Usage: "Number of most frequent values for string and enum keys",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return schemaDiff(c)
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/statecrafthq/borg/commands/ops"
+	"github.com/statecrafthq/borg/utils"
+	"github.com/urfave/cli"
+)
+
+func formatQuantiles(q *ops.Quantiles) string {
+	if q == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.2f / %.2f / %.2f / %.2f / %.2f", q.P5, q.P25, q.P50, q.P75, q.P95)
+}
+
+func printStats(stats *ops.DatasetStats) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Records\t%d\n", stats.Records)
+	fmt.Fprintf(w, "Retired\t%d\n", stats.Retired)
+	fmt.Fprintf(w, "With geometry\t%d\n", stats.Geometry.Count)
+	fmt.Fprintf(w, "Multipolygons\t%d\n", stats.Geometry.Multipolygons)
+	fmt.Fprintf(w, "With holes\t%d\n", stats.Geometry.WithHoles)
+	if stats.Geometry.Bounds != nil {
+		b := stats.Geometry.Bounds
+		fmt.Fprintf(w, "Bounds\t%f,%f - %f,%f\n", b.MinLongitude, b.MinLatitude, b.MaxLongitude, b.MaxLatitude)
+		fmt.Fprintf(w, "Area min / mean / max\t%.2f / %.2f / %.2f\n", stats.Geometry.AreaMin, stats.Geometry.AreaMean, stats.Geometry.AreaMax)
+		fmt.Fprintf(w, "Area p5 / p25 / p50 / p75 / p95\t%s\n", formatQuantiles(stats.Geometry.Area))
+	}
+	for _, b := range stats.Geometry.Vertices {
+		fmt.Fprintf(w, "Vertices <= %d\t%d\n", b.Max, b.Count)
+	}
+	w.Flush()
+	fmt.Println()
+
+	w = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tTYPE\tFILL\tMIN\tMEAN\tMAX\tP5 / P25 / P50 / P75 / P95\tTOP")
+	for _, k := range stats.Extras {
+		min := ""
+		mean := ""
+		max := ""
+		if k.Min != nil {
+			min = fmt.Sprintf("%.2f", *k.Min)
+			mean = fmt.Sprintf("%.2f", *k.Mean)
+			max = fmt.Sprintf("%.2f", *k.Max)
+		}
+		top := make([]string, 0)
+		for _, v := range k.Top {
+			top = append(top, fmt.Sprintf("%s (%d)", v.Value, v.Count))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%.1f%%\t%s\t%s\t%s\t%s\t%s\n", k.Key, k.Type, k.FillRate*100, min, mean, max, formatQuantiles(k.Quantiles), strings.Join(top, ", "))
+	}
+	w.Flush()
+}
+
+func stats(c *cli.Context) error {
+	src := c.String("src")
+	if src == "" {
+		return cli.NewExitError("You should provide source file", 1)
+	}
+	jsonPath := c.String("json")
+	if jsonPath != "" {
+		e := utils.AssumeNotExists(jsonPath, c.Bool("force"))
+		if e != nil {
+			return e
+		}
+	}
+	builder := ops.NewStatsBuilder()
+	e := ops.RecordReader(src, func(row map[string]interface{}) error {
+		return builder.Add(row)
+	})
+	if e != nil {
+		return e
+	}
+	res := builder.Build(c.Int("top"))
+	printStats(res)
+	if jsonPath != "" {
+		data, e := json.MarshalIndent(res, "", "  ")
+		if e != nil {
+			return e
+		}
+		return ioutil.WriteFile(jsonPath, data, 0644)
+	}
+	return nil
+}
+
+func CreateStatsCommands() []cli.Command {
+	return []cli.Command{
+		{
+			Name:  "stats",
+			Usage: "Profile dataset",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "source, src",
+					Usage: "Path to dataset",
+				},
+				cli.StringFlag{
+					Name:  "json",
+					Usage: "Path to JSON output",
+				},
+				cli.IntFlag{
+					Name:  "top",
+					Value: 5,
+					Usage: "Number of most frequent values for string and enum keys",
+				},
+				cli.BoolFlag{
+					Name:  "force, f",
+					Usage: "Overwrite file if exists",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return stats(c)
+			},
+		},
+	}
+}
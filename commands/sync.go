@@ -51,8 +51,32 @@ func sync(c *cli.Context) error {
 		return nil
 	}
 
-	// Upload new version
+	// Checking schema of extras
 	log.Println("Dataset was changed")
+	var schema *ops.Schema
+	schemaPath := "imports/" + name + "/SCHEMA"
+	if filepath.Ext(file) == ".ols" {
+		schema, err = ops.InferSchema(file, 10)
+		if err != nil {
+			return err
+		}
+		previous, err := ops.ReadRemoteSchema(schemaPath)
+		if err != nil {
+			return err
+		}
+		if previous != nil {
+			changes := ops.DiffSchemas(previous, schema, c.Float64("max-fill-drop"))
+			if len(changes) > 0 {
+				log.Println("WARNING: Schema of dataset was changed")
+				breaking := printSchemaChanges(changes)
+				if breaking > 0 && c.Bool("strict-schema") {
+					return cli.NewExitError("Keys were removed or retyped", 1)
+				}
+			}
+		}
+	}
+
+	// Upload new version
 	ext := filepath.Ext(file)
 	fname := name + "_" + (time.Now().Format("2006_01_02_150405")) + ext
 	err = ops.UploadFile(name, fname, file)
@@ -60,7 +84,13 @@ func sync(c *cli.Context) error {
 		return err
 	}
 
-	// Persisting state
+	// Persisting state, schema goes first so unchanged status never hides a missing schema
+	if schema != nil {
+		err = ops.WriteRemoteSchema(schemaPath, schema)
+		if err != nil {
+			return err
+		}
+	}
 	err = ops.WriteStatus(statusPath, hash, fname)
 	if err != nil {
 		return err
@@ -131,6 +161,15 @@ func CreateSyncCommands() []cli.Command {
 					Name:  "name",
 					Usage: "Unique name of dataset",
 				},
+				cli.BoolFlag{
+					Name:  "strict-schema",
+					Usage: "Abort if extras keys were removed or retyped",
+				},
+				cli.Float64Flag{
+					Name:  "max-fill-drop",
+					Value: 0.2,
+					Usage: "Warn about keys which fill rate dropped more than this value",
+				},
 			},
 			Action: func(c *cli.Context) error {
 				return sync(c)
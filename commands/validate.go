@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/statecrafthq/borg/commands/ops"
+	"github.com/statecrafthq/borg/utils"
+	"github.com/urfave/cli"
+	emoji "gopkg.in/kyokomi/emoji.v1"
+)
+
+func validate(c *cli.Context) error {
+	src := c.String("src")
+	if src == "" {
+		return cli.NewExitError("You should provide source file", 1)
+	}
+	rules := ops.DefaultValidationRules()
+	if c.String("rules") != "" {
+		var e error
+		rules, e = ops.LoadValidationRules(c.String("rules"))
+		if e != nil {
+			return e
+		}
+	}
+	var report *ops.CSVReport
+	if c.String("report") != "" {
+		e := utils.AssumeNotExists(c.String("report"), c.Bool("force"))
+		if e != nil {
+			return e
+		}
+		report, e = ops.NewCSVReport(c.String("report"), []string{"id", "rule", "message"})
+		if e != nil {
+			return e
+		}
+		defer report.Close()
+	}
+
+	//
+	// Checking records
+	//
+
+	emoji.Println(":mag: Validating dataset")
+	validator := ops.NewValidator(rules)
+	total := 0
+	failed := 0
+	counts := make(map[string]int)
+	e := ops.RecordReader(src, func(row map[string]interface{}) error {
+		total++
+		violations := validator.Validate(row)
+		if len(violations) > 0 {
+			failed++
+		}
+		for _, v := range violations {
+			counts[v.Rule]++
+			if report != nil {
+				e := report.Write([]string{v.ID, v.Rule, v.Message})
+				if e != nil {
+					return e
+				}
+			} else if c.Bool("verbose") {
+				fmt.Printf("%s: [%s] %s\n", v.ID, v.Rule, v.Message)
+			}
+		}
+		return nil
+	})
+	if e != nil {
+		return e
+	}
+
+	emoji.Printf(":bar_chart: Stats:\n")
+	fmt.Printf("-- Total: %d\n", total)
+	fmt.Printf("-- Failed: %d\n", failed)
+	keys := make([]string, 0)
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Printf("-- %s: %d\n", k, counts[k])
+	}
+	if failed > 0 {
+		return cli.NewExitError(fmt.Sprintf("Validation failed for %d records", failed), 1)
+	}
+	return nil
+}
+
+func CreateValidateCommands() []cli.Command {
+	return []cli.Command{
+		{
+			Name:  "validate",
+			Usage: "Validate dataset before import",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "source, src",
+					Usage: "Path to dataset",
+				},
+				cli.StringFlag{
+					Name:  "rules",
+					Usage: "Path to JSON file with validation rules (only ids and geometry are checked by default)",
+				},
+				cli.StringFlag{
+					Name:  "report",
+					Usage: "Path to CSV report of violations",
+				},
+				cli.BoolFlag{
+					Name:  "verbose",
+					Usage: "Print violations if report is not written",
+				},
+				cli.BoolFlag{
+					Name:  "force, f",
+					Usage: "Overwrite report if exists",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return validate(c)
+			},
+		},
+	}
+}
@@ -88,12 +88,10 @@ func overlay(c *cli.Context) error {
 	}
 
 	//
-	// Prepare projection from center of zoning data
+	// Prepare projection for zoning data
 	//
 
-	centerLatitude := (maxLat + minLat) / 2.0
-	centerLongitude := (maxLon + minLon) / 2.0
-	proj := geometry.NewProjection(geometry.PointGeo{Latitude: centerLatitude, Longitude: centerLongitude})
+	proj := geometry.PickProjection(geometry.BoundsGeo{MinLatitude: minLat, MinLongitude: minLon, MaxLatitude: maxLat, MaxLongitude: maxLon}, geometry.MaxProjectionDistortion)
 
 	//
 	// Project zoning
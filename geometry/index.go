@@ -0,0 +1,76 @@
+package geometry
+
+import "math"
+
+type gridCell struct {
+	x int
+	y int
+}
+
+// GridIndex is a simple uniform grid spatial index of bounding boxes
+type GridIndex struct {
+	cellSize float64
+	cells    map[gridCell][]int
+	bounds   []Bounds
+}
+
+// NewGridIndex creates index with specific cell size, that should be close to size of indexed objects
+func NewGridIndex(cellSize float64) *GridIndex {
+	return &GridIndex{cellSize: cellSize, cells: make(map[gridCell][]int), bounds: make([]Bounds, 0)}
+}
+
+func (index *GridIndex) cellRange(b Bounds) (int, int, int, int) {
+	return int(math.Floor(b.MinX / index.cellSize)), int(math.Floor(b.MinY / index.cellSize)),
+		int(math.Floor(b.MaxX / index.cellSize)), int(math.Floor(b.MaxY / index.cellSize))
+}
+
+// Insert adds bounding box to index and returns it's id
+func (index *GridIndex) Insert(b Bounds) int {
+	id := len(index.bounds)
+	index.bounds = append(index.bounds, b)
+	minX, minY, maxX, maxY := index.cellRange(b)
+	for x := minX; x <= maxX; x++ {
+		for y := minY; y <= maxY; y++ {
+			c := gridCell{x: x, y: y}
+			index.cells[c] = append(index.cells[c], id)
+		}
+	}
+	return id
+}
+
+// Query returns ids of all bounding boxes that intersects or touches b
+func (index *GridIndex) Query(b Bounds) []int {
+	res := make([]int, 0)
+	seen := make(map[int]bool)
+	minX, minY, maxX, maxY := index.cellRange(b)
+	for x := minX; x <= maxX; x++ {
+		for y := minY; y <= maxY; y++ {
+			for _, id := range index.cells[gridCell{x: x, y: y}] {
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+				o := index.bounds[id]
+				if o.MinX <= b.MaxX && o.MaxX >= b.MinX && o.MinY <= b.MaxY && o.MaxY >= b.MinY {
+					res = append(res, id)
+				}
+			}
+		}
+	}
+	return res
+}
+
+// Expand grows bounds by distance in all directions
+func (a Bounds) Expand(distance float64) Bounds {
+	return Bounds{MinX: a.MinX - distance, MinY: a.MinY - distance, MaxX: a.MaxX + distance, MaxY: a.MaxY + distance}
+}
+
+// Bounds is a bounding box of an edge
+func (edge Edge2D) Bounds() Bounds {
+	return Bounds{
+		MinX: math.Min(edge.Start.X, edge.End.X),
+		MinY: math.Min(edge.Start.Y, edge.End.Y),
+		MaxX: math.Max(edge.Start.X, edge.End.X),
+		MaxY: math.Max(edge.Start.Y, edge.End.Y),
+	}
+}
@@ -0,0 +1,22 @@
+package geometry
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGridIndex(t *testing.T) {
+	index := NewGridIndex(10)
+	a := index.Insert(Bounds{MinX: 0, MinY: 0, MaxX: 5, MaxY: 5})
+	b := index.Insert(Bounds{MinX: 5, MinY: 0, MaxX: 25, MaxY: 5})
+	c := index.Insert(Bounds{MinX: -30, MinY: -30, MaxX: -20, MaxY: -20})
+
+	res := index.Query(Bounds{MinX: 4, MinY: 1, MaxX: 6, MaxY: 2})
+	sort.Ints(res)
+	assert.Equal(t, []int{a, b}, res)
+	assert.Equal(t, []int{b}, index.Query(Bounds{MinX: 20, MinY: 1, MaxX: 21, MaxY: 2}))
+	assert.Equal(t, []int{c}, index.Query(Bounds{MinX: -25, MinY: -25, MaxX: -25, MaxY: -25}))
+	assert.Equal(t, 0, len(index.Query(Bounds{MinX: 100, MinY: 100, MaxX: 101, MaxY: 101})))
+}
@@ -0,0 +1,212 @@
+package geometry
+
+import "math"
+
+// InscribedResolution is a number of grid cells along longest side of a polygon
+const InscribedResolution = 32
+
+// InscribedAngleStep is a step of rotation search (in radians)
+const InscribedAngleStep = math.Pi / 90
+
+// Rectangle2D is an oriented rectangle: Width is always smaller side, Length is aligned with
+// rotated Y axis, same as footprints in layout
+type Rectangle2D struct {
+	Center Point2D
+	Width  float64
+	Length float64
+	Angle  float64
+}
+
+// Area of a rectangle
+func (rect Rectangle2D) Area() float64 {
+	return rect.Width * rect.Length
+}
+
+// Polygon converts rectangle to a polygon
+func (rect Rectangle2D) Polygon() Polygon2D {
+	return NewSimplePolygon([]Point2D{
+		{X: -rect.Width / 2, Y: rect.Length / 2},
+		{X: rect.Width / 2, Y: rect.Length / 2},
+		{X: rect.Width / 2, Y: -rect.Length / 2},
+		{X: -rect.Width / 2, Y: -rect.Length / 2},
+	}).Rotate(rect.Angle).Shift(rect.Center)
+}
+
+// maxHistogramRectangle finds largest rectangle under histogram
+func maxHistogramRectangle(heights []int) (int, int, int) {
+	bestArea := 0
+	bestStart := 0
+	bestWidth := 0
+	stack := make([]int, 0)
+	for i := 0; i <= len(heights); i++ {
+		h := 0
+		if i < len(heights) {
+			h = heights[i]
+		}
+		for len(stack) > 0 && heights[stack[len(stack)-1]] >= h {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			start := 0
+			if len(stack) > 0 {
+				start = stack[len(stack)-1] + 1
+			}
+			area := heights[top] * (i - start)
+			if area > bestArea {
+				bestArea = area
+				bestStart = start
+				bestWidth = i - start
+			}
+		}
+		stack = append(stack, i)
+	}
+	if bestWidth == 0 {
+		return 0, 0, 0
+	}
+	return bestArea, bestStart, bestWidth
+}
+
+// axisAlignedInscribed searches for largest axis aligned rectangle on a grid
+func (poly Polygon2D) axisAlignedInscribed() (bool, Bounds, float64) {
+	bounds := poly.Bounds()
+	cell := math.Max(bounds.MaxX-bounds.MinX, bounds.MaxY-bounds.MinY) / InscribedResolution
+	if cell < eps {
+		return false, Bounds{}, 0
+	}
+	cols := int(math.Ceil((bounds.MaxX - bounds.MinX) / cell))
+	rows := int(math.Ceil((bounds.MaxY - bounds.MinY) / cell))
+
+	// Grid corners inside of polygon
+	corners := make([][]bool, rows+1)
+	for r := 0; r <= rows; r++ {
+		corners[r] = make([]bool, cols+1)
+		for c := 0; c <= cols; c++ {
+			corners[r][c] = poly.ContainsPoint(Point2D{X: bounds.MinX + float64(c)*cell, Y: bounds.MinY + float64(r)*cell})
+		}
+	}
+
+	// Cells that have vertices inside could be cut by polygon
+	blocked := make([][]bool, rows)
+	for r := range blocked {
+		blocked[r] = make([]bool, cols)
+	}
+	block := func(ring LineString2D) {
+		for _, p := range ring {
+			c := int((p.X - bounds.MinX) / cell)
+			r := int((p.Y - bounds.MinY) / cell)
+			if c >= 0 && c < cols && r >= 0 && r < rows {
+				blocked[r][c] = true
+			}
+		}
+	}
+	block(poly.Polygon)
+	for _, h := range poly.Holes {
+		block(h)
+	}
+
+	// Largest rectangle of inside cells
+	heights := make([]int, cols)
+	bestArea := 0
+	var best Bounds
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			if !blocked[r][c] && corners[r][c] && corners[r][c+1] && corners[r+1][c] && corners[r+1][c+1] {
+				heights[c]++
+			} else {
+				heights[c] = 0
+			}
+		}
+		area, start, width := maxHistogramRectangle(heights)
+		if area > bestArea {
+			bestArea = area
+			height := area / width
+			best = Bounds{
+				MinX: bounds.MinX + float64(start)*cell,
+				MaxX: bounds.MinX + float64(start+width)*cell,
+				MinY: bounds.MinY + float64(r+1-height)*cell,
+				MaxY: bounds.MinY + float64(r+1)*cell,
+			}
+		}
+	}
+	return bestArea > 0, best, cell
+}
+
+func boundsPolygon(b Bounds) Polygon2D {
+	return NewSimplePolygon([]Point2D{{X: b.MinX, Y: b.MinY}, {X: b.MaxX, Y: b.MinY}, {X: b.MaxX, Y: b.MaxY}, {X: b.MinX, Y: b.MaxY}})
+}
+
+// refineInscribed moves each side of a grid rectangle outside while it is still inside of a polygon
+func (poly Polygon2D) refineInscribed(b Bounds, cell float64) (bool, Bounds) {
+	inset := cell * 0.001
+	b = Bounds{MinX: b.MinX + inset, MinY: b.MinY + inset, MaxX: b.MaxX - inset, MaxY: b.MaxY - inset}
+	if !poly.ContainsWithHoles(boundsPolygon(b)) {
+		return false, Bounds{}
+	}
+	sides := []func(b Bounds, d float64) Bounds{
+		func(b Bounds, d float64) Bounds { b.MinX -= d; return b },
+		func(b Bounds, d float64) Bounds { b.MaxX += d; return b },
+		func(b Bounds, d float64) Bounds { b.MinY -= d; return b },
+		func(b Bounds, d float64) Bounds { b.MaxY += d; return b },
+	}
+	for _, side := range sides {
+		lo := 0.0
+		hi := cell * 2
+		for i := 0; i < 8; i++ {
+			mid := (lo + hi) / 2
+			if poly.ContainsWithHoles(boundsPolygon(side(b, mid))) {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		b = side(b, lo)
+	}
+	return true, b
+}
+
+func (poly Polygon2D) inscribedAt(angle float64) (bool, Rectangle2D) {
+	rotated := poly.Rotate(-angle)
+	ok, b, cell := rotated.axisAlignedInscribed()
+	if !ok {
+		return false, Rectangle2D{}
+	}
+	ok, b = rotated.refineInscribed(b, cell)
+	if !ok {
+		return false, Rectangle2D{}
+	}
+	return true, rectangleFromBounds(b, angle)
+}
+
+// MaxInscribedRectangle finds approximate largest rectangle inside of a polygon. If edgeAligned is set
+// only rectangles that are parallel to one of edges are considered.
+func (poly Polygon2D) MaxInscribedRectangle(edgeAligned bool) (bool, Rectangle2D) {
+	if len(poly.Polygon) < 3 {
+		return false, Rectangle2D{}
+	}
+
+	// Rectangles are symmetric, so search only in [0, PI/2)
+	angles := make([]float64, 0)
+	if edgeAligned {
+		for _, a := range poly.Azimuths() {
+			a = math.Mod(a, math.Pi/2)
+			if a < 0 {
+				a += math.Pi / 2
+			}
+			angles = append(angles, a)
+		}
+	} else {
+		for a := 0.0; a < math.Pi/2; a += InscribedAngleStep {
+			angles = append(angles, a)
+		}
+	}
+
+	found := false
+	var best Rectangle2D
+	for _, a := range angles {
+		ok, r := poly.inscribedAt(a)
+		if ok && (!found || r.Area() > best.Area()) {
+			found = true
+			best = r
+		}
+	}
+	return found, best
+}
@@ -0,0 +1,49 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxInscribedRectangle(t *testing.T) {
+	square := NewSimplePolygon([]Point2D{{0, 0}, {10, 0}, {10, 10}, {0, 10}})
+	ok, res := square.MaxInscribedRectangle(true)
+	assert.True(t, ok)
+	assert.InDelta(t, 10, res.Width, 1)
+	assert.InDelta(t, 10, res.Length, 1)
+	assert.InDelta(t, 5, res.Center.X, 0.5)
+	assert.InDelta(t, 5, res.Center.Y, 0.5)
+	assert.True(t, square.Contains(res.Polygon()))
+
+	// Long side is always reported as length
+	strip := NewSimplePolygon([]Point2D{{0, 0}, {20, 0}, {20, 4}, {0, 4}})
+	ok, res = strip.MaxInscribedRectangle(true)
+	assert.True(t, ok)
+	assert.InDelta(t, 4, res.Width, 0.5)
+	assert.InDelta(t, 20, res.Length, 1)
+	assert.InDelta(t, math.Pi/2, res.Angle, 0.000001)
+	assert.True(t, strip.Contains(res.Polygon()))
+
+	// Rotated square is found at any orientation
+	rotated := square.Rotate(math.Pi / 6)
+	ok, res = rotated.MaxInscribedRectangle(false)
+	assert.True(t, ok)
+	assert.InDelta(t, 100, res.Area(), 15)
+	assert.True(t, rotated.Contains(res.Polygon()))
+
+	// Right triangle: best rectangle is half of it's area
+	triangle := NewSimplePolygon([]Point2D{{0, 0}, {10, 0}, {0, 10}})
+	ok, res = triangle.MaxInscribedRectangle(true)
+	assert.True(t, ok)
+	assert.InDelta(t, 25, res.Area(), 4)
+	assert.True(t, triangle.Contains(res.Polygon()))
+
+	// Rectangle avoids holes
+	withHole := Polygon2D{Polygon: []Point2D{{0, 0}, {10, 0}, {10, 10}, {0, 10}}, Holes: [][]Point2D{{{0, 4}, {6, 4}, {6, 6}, {0, 6}}}}
+	ok, res = withHole.MaxInscribedRectangle(true)
+	assert.True(t, ok)
+	assert.True(t, withHole.ContainsWithHoles(res.Polygon()))
+	assert.True(t, res.Area() < 50)
+}
@@ -0,0 +1,166 @@
+package geometry
+
+import (
+	"math"
+	"sort"
+)
+
+// Metrics describes shape of a parcel
+type Metrics struct {
+	Area      float64
+	Perimeter float64
+	// Area of a convex hull
+	HullArea float64
+	// Ratio of area to convex hull area, 1 for convex polygons
+	Convexity float64
+	// Polsby-Popper compactness: 4*PI*Area/Perimeter^2, 1 for a circle
+	Compactness float64
+	// 1 - width/length of minimum bounding rectangle, 0 for a square
+	Elongation float64
+	// Minimum-area oriented bounding rectangle
+	BoundingRectangle Rectangle2D
+	// Estimated frontage and depth: short and long sides of bounding rectangle
+	Frontage float64
+	Depth    float64
+}
+
+func ringLength(points []Point2D) float64 {
+	res := 0.0
+	for i := range points {
+		res += points[i].Distance(points[(i+1)%len(points)])
+	}
+	return res
+}
+
+// Perimeter of a polygon including holes
+func (poly Polygon2D) Perimeter() float64 {
+	res := ringLength(poly.Polygon)
+	for _, h := range poly.Holes {
+		res += ringLength(h)
+	}
+	return res
+}
+
+func cross(o Point2D, a Point2D, b Point2D) float64 {
+	return (a.X-o.X)*(b.Y-o.Y) - (a.Y-o.Y)*(b.X-o.X)
+}
+
+// ConvexHull builds counter clockwise convex hull of points (monotone chain)
+func ConvexHull(points []Point2D) LineString2D {
+	sorted := make([]Point2D, len(points))
+	copy(sorted, points)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].X == sorted[j].X {
+			return sorted[i].Y < sorted[j].Y
+		}
+		return sorted[i].X < sorted[j].X
+	})
+	if len(sorted) < 3 {
+		return sorted
+	}
+	hull := make([]Point2D, 0)
+	for _, p := range sorted {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= eps {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	lower := len(hull) + 1
+	for i := len(sorted) - 2; i >= 0; i-- {
+		p := sorted[i]
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= eps {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	return hull[:len(hull)-1]
+}
+
+// ConvexHull of outer ring of a polygon
+func (poly Polygon2D) ConvexHull() Polygon2D {
+	return NewSimplePolygon(ConvexHull(poly.Polygon))
+}
+
+// ConvexHull of all components of a multipolygon
+func (multipoly Multipolygon2D) ConvexHull() Polygon2D {
+	points := make([]Point2D, 0)
+	for _, p := range multipoly.Polygons {
+		points = append(points, p.Polygon...)
+	}
+	return NewSimplePolygon(ConvexHull(points))
+}
+
+// rectangleFromBounds converts bounds of a polygon rotated by -angle to an oriented rectangle
+func rectangleFromBounds(b Bounds, angle float64) Rectangle2D {
+	dx := b.MaxX - b.MinX
+	dy := b.MaxY - b.MinY
+	center := Point2D{X: (b.MinX + b.MaxX) / 2, Y: (b.MinY + b.MaxY) / 2}.Rotate(angle)
+	if dx > dy {
+		return Rectangle2D{Center: center, Width: dy, Length: dx, Angle: math.Mod(angle+math.Pi/2, math.Pi)}
+	}
+	return Rectangle2D{Center: center, Width: dx, Length: dy, Angle: angle}
+}
+
+// MinBoundingRectangle finds minimum-area oriented bounding rectangle. One of it's sides
+// is always collinear with a convex hull edge.
+func (poly Polygon2D) MinBoundingRectangle() (bool, Rectangle2D) {
+	hull := poly.ConvexHull()
+	if len(hull.Polygon) < 3 {
+		return false, Rectangle2D{}
+	}
+	found := false
+	var best Rectangle2D
+	for _, a := range hull.Azimuths() {
+		a = math.Mod(a, math.Pi/2)
+		if a < 0 {
+			a += math.Pi / 2
+		}
+		r := rectangleFromBounds(hull.Rotate(-a).Bounds(), a)
+		if !found || r.Area() < best.Area() {
+			found = true
+			best = r
+		}
+	}
+	return found, best
+}
+
+func buildMetrics(area float64, perimeter float64, hull Polygon2D) Metrics {
+	res := Metrics{Area: area, Perimeter: perimeter, HullArea: hull.Area()}
+	if res.HullArea > eps {
+		res.Convexity = area / res.HullArea
+	}
+	if perimeter > eps {
+		res.Compactness = 4 * math.Pi * area / (perimeter * perimeter)
+	}
+	ok, rect := hull.MinBoundingRectangle()
+	if ok {
+		res.BoundingRectangle = rect
+		res.Frontage = rect.Width
+		res.Depth = rect.Length
+		if rect.Length > eps {
+			res.Elongation = 1 - rect.Width/rect.Length
+		}
+	}
+	return res
+}
+
+// Metrics calculates shape metrics of a polygon
+func (poly Polygon2D) Metrics() Metrics {
+	return buildMetrics(poly.Area(), poly.Perimeter(), poly.ConvexHull())
+}
+
+// Metrics calculates shape metrics of all components of a multipolygon as a single shape
+func (multipoly Multipolygon2D) Metrics() Metrics {
+	area := 0.0
+	perimeter := 0.0
+	for _, p := range multipoly.Polygons {
+		area += p.Area()
+		perimeter += p.Perimeter()
+	}
+	return buildMetrics(area, perimeter, multipoly.ConvexHull())
+}
+
+// Clockwise checks orientation of outer ring
+func (poly Polygon2D) Clockwise() bool {
+	return signedArea(poly.Polygon) < 0
+}
@@ -0,0 +1,45 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvexHull(t *testing.T) {
+	lshape := NewSimplePolygon([]Point2D{{0, 0}, {10, 0}, {10, 4}, {4, 4}, {4, 10}, {0, 10}})
+	hull := lshape.ConvexHull()
+	assert.Equal(t, 5, len(hull.Polygon))
+	assert.InEpsilon(t, 82, hull.Area(), 0.000001)
+}
+
+func TestMetrics(t *testing.T) {
+	strip := NewSimplePolygon([]Point2D{{0, 0}, {20, 0}, {20, 5}, {0, 5}}).Rotate(math.Pi / 5)
+	m := strip.Metrics()
+	assert.InEpsilon(t, 100, m.Area, 0.000001)
+	assert.InEpsilon(t, 50, m.Perimeter, 0.000001)
+	assert.InEpsilon(t, 1, m.Convexity, 0.000001)
+	assert.InEpsilon(t, 4*math.Pi*100/2500, m.Compactness, 0.000001)
+	assert.InEpsilon(t, 0.75, m.Elongation, 0.000001)
+	assert.InEpsilon(t, 5, m.Frontage, 0.000001)
+	assert.InEpsilon(t, 20, m.Depth, 0.000001)
+
+	// Holes reduce area, but not hull
+	withHole := Polygon2D{Polygon: []Point2D{{0, 0}, {10, 0}, {10, 10}, {0, 10}}, Holes: [][]Point2D{{{4, 4}, {6, 4}, {6, 6}, {4, 6}}}}
+	m = withHole.Metrics()
+	assert.InEpsilon(t, 96, m.Area, 0.000001)
+	assert.InEpsilon(t, 48, m.Perimeter, 0.000001)
+	assert.InEpsilon(t, 0.96, m.Convexity, 0.000001)
+	assert.InDelta(t, 0, m.Elongation, 0.000001)
+
+	// Multipolygons are measured as a single shape
+	multi := Multipolygon2D{Polygons: []Polygon2D{
+		NewSimplePolygon([]Point2D{{0, 0}, {1, 0}, {1, 1}, {0, 1}}),
+		NewSimplePolygon([]Point2D{{3, 0}, {4, 0}, {4, 1}, {3, 1}}),
+	}}
+	m = multi.Metrics()
+	assert.InEpsilon(t, 2, m.Area, 0.000001)
+	assert.InEpsilon(t, 4, m.HullArea, 0.000001)
+	assert.InEpsilon(t, 0.75, m.Elongation, 0.000001)
+}
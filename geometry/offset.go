@@ -0,0 +1,176 @@
+package geometry
+
+import "math"
+
+type offsetLine struct {
+	start  Point2D
+	end    Point2D
+	dx     float64
+	dy     float64
+	nx     float64
+	ny     float64
+	offset float64
+}
+
+func signedArea(points []Point2D) float64 {
+	area := 0.0
+	j := len(points) - 1
+	for i := range points {
+		area += points[j].X*points[i].Y - points[i].X*points[j].Y
+		j = i
+	}
+	return area / 2
+}
+
+func (line offsetLine) origin() Point2D {
+	return Point2D{X: line.start.X + line.nx*line.offset, Y: line.start.Y + line.ny*line.offset}
+}
+
+func offsetVertex(a offsetLine, b offsetLine) Point2D {
+	// Almost parallel edges: use shifted start of next edge to avoid far away miter points
+	cross := a.dx*b.dy - a.dy*b.dx
+	if math.Abs(cross) < 1e-6 {
+		return b.origin()
+	}
+	ao := a.origin()
+	bo := b.origin()
+	t := ((bo.X-ao.X)*b.dy - (bo.Y-ao.Y)*b.dx) / cross
+	return Point2D{X: ao.X + a.dx*t, Y: ao.Y + a.dy*t}
+}
+
+// OffsetMaxSplits is a maximum number of self intersections resolved in offset ring
+const OffsetMaxSplits = 32
+
+// splitLoops splits self intersecting ring at crossing points to loops without crossings
+func splitLoops(ring LineString2D, depth int) []LineString2D {
+	n := len(ring)
+	if depth <= 0 || n < 4 {
+		return []LineString2D{ring}
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 2; j < n; j++ {
+			if i == 0 && j == n-1 {
+				continue
+			}
+			if !segmentIntersection(ring[i], ring[i+1], ring[j], ring[(j+1)%n]) {
+				continue
+			}
+			ok, p := lineIntersection(ring[i], ring[i+1], ring[j], ring[(j+1)%n])
+			if !ok {
+				continue
+			}
+			loop1 := append(LineString2D{p}, ring[i+1:j+1]...)
+			loop2 := append(LineString2D{p}, ring[j+1:]...)
+			loop2 = append(loop2, ring[:i+1]...)
+			return append(splitLoops(loop1, depth-1), splitLoops(loop2, depth-1)...)
+		}
+	}
+	return []LineString2D{ring}
+}
+
+// offsetRing moves each edge of a ring to it's left side (inside for counter clockwise rings)
+// and rebuilds vertices. Edges that are collapsed by offset are removed. When parts of a ring
+// are collapsed or split (narrow necks of concave polygons) moved edges cross each other, such
+// ring is split to loops and largest loop that keeps orientation is returned.
+func offsetRing(ring LineString2D, distances []float64) (bool, LineString2D) {
+	if len(ring) < 3 {
+		return false, nil
+	}
+	orientation := 1.0
+	if signedArea(ring) < 0 {
+		orientation = -1.0
+	}
+
+	// Building offset lines for all non empty edges
+	lines := make([]offsetLine, 0)
+	for i := 0; i < len(ring); i++ {
+		s := ring[i]
+		e := ring[(i+1)%len(ring)]
+		l := s.Distance(e)
+		if l < eps {
+			continue
+		}
+		dx := (e.X - s.X) / l
+		dy := (e.Y - s.Y) / l
+		lines = append(lines, offsetLine{start: s, end: e, dx: dx, dy: dy, nx: -dy * orientation, ny: dx * orientation, offset: distances[i]})
+	}
+
+	// Removing collapsed edges until all edges keep their directions
+	for {
+		n := len(lines)
+		if n < 3 {
+			return false, nil
+		}
+		vertices := make([]Point2D, n)
+		for i := 0; i < n; i++ {
+			vertices[i] = offsetVertex(lines[(i+n-1)%n], lines[i])
+		}
+		valid := make([]offsetLine, 0)
+		for i := 0; i < n; i++ {
+			s := vertices[i]
+			e := vertices[(i+1)%n]
+			if (e.X-s.X)*lines[i].dx+(e.Y-s.Y)*lines[i].dy > eps {
+				valid = append(valid, lines[i])
+			}
+		}
+		if len(valid) == n {
+			found := false
+			var res LineString2D
+			for _, loop := range splitLoops(LineString2D(vertices), OffsetMaxSplits) {
+				area := signedArea(loop) * orientation
+				if area > eps && (!found || area > signedArea(res)*orientation) {
+					found = true
+					res = loop
+				}
+			}
+			if !found || !NewSimplePolygon(res).IsSimple() {
+				return false, nil
+			}
+			return true, res
+		}
+		lines = valid
+	}
+}
+
+// Offset moves edges of a polygon inside. Distance for each edge of outer ring
+// is provided in distances, holes are expanded by holeDistance. If polygon is split by
+// offset largest part is returned. Returns false if polygon is collapsed or if expanded hole
+// crosses outer ring since result can't be represented without clipping.
+func (poly Polygon2D) Offset(distances []float64, holeDistance float64) (bool, Polygon2D) {
+	ok, main := offsetRing(poly.Polygon, distances)
+	if !ok {
+		return false, Polygon2D{}
+	}
+	holes := make([]LineString2D, 0)
+	for _, h := range poly.Holes {
+		d := make([]float64, len(h))
+		for i := range d {
+			d[i] = -holeDistance
+		}
+		ok, hole := offsetRing(h, d)
+		if !ok {
+			continue
+		}
+		if isLineStringsCrossing(hole, main) || containsPoint(main[0], hole) {
+			return false, Polygon2D{}
+		}
+		// Holes outside of a part that is left are dropped
+		if containsPoint(hole[0], main) {
+			holes = append(holes, hole)
+		}
+	}
+	res := Polygon2D{Polygon: main, Holes: holes}
+	if !res.IsSimple() {
+		return false, Polygon2D{}
+	}
+	return true, res
+}
+
+// Buffer moves all edges of a polygon inside by same distance
+func (poly Polygon2D) Buffer(distance float64) (bool, Polygon2D) {
+	distances := make([]float64, len(poly.Polygon))
+	for i := range distances {
+		distances[i] = distance
+	}
+	return poly.Offset(distances, distance)
+}
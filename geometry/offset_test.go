@@ -0,0 +1,79 @@
+package geometry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuffer(t *testing.T) {
+	square := NewSimplePolygon([]Point2D{{0, 0}, {10, 0}, {10, 10}, {0, 10}})
+	ok, res := square.Buffer(1)
+	assert.True(t, ok)
+	assert.InEpsilon(t, 64, res.Area(), 0.000001)
+	assert.True(t, square.Contains(res))
+
+	// Orientation should not matter
+	ok, res = NewSimplePolygon([]Point2D{{0, 10}, {10, 10}, {10, 0}, {0, 0}}).Buffer(1)
+	assert.True(t, ok)
+	assert.InEpsilon(t, 64, res.Area(), 0.000001)
+
+	// Concave polygon
+	lshape := NewSimplePolygon([]Point2D{{0, 0}, {10, 0}, {10, 4}, {4, 4}, {4, 10}, {0, 10}})
+	ok, res = lshape.Buffer(1)
+	assert.True(t, ok)
+	assert.InEpsilon(t, 28, res.Area(), 0.000001)
+
+	// Too narrow polygon
+	ok, _ = NewSimplePolygon([]Point2D{{0, 0}, {10, 0}, {10, 2}, {0, 2}}).Buffer(1.5)
+	assert.False(t, ok)
+
+	// Thin arm of concave polygon collapses
+	thinArm := NewSimplePolygon([]Point2D{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 6}, {X: 2, Y: 6}, {X: 2, Y: 10}, {X: 0, Y: 10}})
+	ok, res = thinArm.Buffer(1.5)
+	assert.True(t, ok)
+	assert.True(t, res.IsSimple())
+	assert.InEpsilon(t, 21, res.Area(), 0.000001)
+
+	// Polygon is split by narrow neck, largest part is kept
+	dumbbell := NewSimplePolygon([]Point2D{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 4}, {X: 14, Y: 4}, {X: 14, Y: 0}, {X: 26, Y: 0},
+		{X: 26, Y: 10}, {X: 14, Y: 10}, {X: 14, Y: 6}, {X: 10, Y: 6}, {X: 10, Y: 10}, {X: 0, Y: 10}})
+	ok, res = dumbbell.Buffer(1.5)
+	assert.True(t, ok)
+	assert.True(t, res.IsSimple())
+	assert.InEpsilon(t, 63, res.Area(), 0.000001)
+	assert.True(t, dumbbell.Contains(res))
+
+	// Expanded hole crosses outer ring
+	nearEdge := Polygon2D{Polygon: []Point2D{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 0, Y: 10}}, Holes: [][]Point2D{{{X: 1.5, Y: 4}, {X: 3, Y: 4}, {X: 3, Y: 6}, {X: 1.5, Y: 6}}}}
+	ok, _ = nearEdge.Buffer(1)
+	assert.False(t, ok)
+
+	// Holes are expanded
+	withHole := Polygon2D{Polygon: []Point2D{{0, 0}, {10, 0}, {10, 10}, {0, 10}}, Holes: [][]Point2D{{{4, 4}, {6, 4}, {6, 6}, {4, 6}}}}
+	ok, res = withHole.Buffer(1)
+	assert.True(t, ok)
+	assert.InEpsilon(t, 48, res.Area(), 0.000001)
+}
+
+func TestOffset(t *testing.T) {
+	square := NewSimplePolygon([]Point2D{{0, 0}, {10, 0}, {10, 10}, {0, 10}})
+
+	// Only first edge is moved
+	ok, res := square.Offset([]float64{2, 0, 0, 0}, 0)
+	assert.True(t, ok)
+	assert.InEpsilon(t, 80, res.Area(), 0.000001)
+	assert.InDelta(t, 2, res.Bounds().MinY, 0.000001)
+
+	// Triangle collapses when one edge is moved too far
+	triangle := NewSimplePolygon([]Point2D{{0, 0}, {10, 0}, {0, 10}})
+	ok, _ = triangle.Offset([]float64{20, 0, 0}, 0)
+	assert.False(t, ok)
+
+	// Short edge disappears after offset
+	chamfered := NewSimplePolygon([]Point2D{{0, 0}, {10, 0}, {10, 9.5}, {9.5, 10}, {0, 10}})
+	ok, res = chamfered.Buffer(1)
+	assert.True(t, ok)
+	assert.Equal(t, 4, len(res.Polygon))
+	assert.InEpsilon(t, 64, res.Area(), 0.000001)
+}
@@ -36,6 +36,17 @@ func (point Point2D) DistanceSq(to Point2D) float64 {
 	return (point.X-to.X)*(point.X-to.X) + (point.Y-to.Y)*(point.Y-to.Y)
 }
 
+// SegmentDistance is a distance from point to segment a-b
+func (point Point2D) SegmentDistance(a Point2D, b Point2D) float64 {
+	lenSq := a.DistanceSq(b)
+	if lenSq < eps {
+		return point.Distance(a)
+	}
+	t := ((point.X-a.X)*(b.X-a.X) + (point.Y-a.Y)*(b.Y-a.Y)) / lenSq
+	t = math.Max(0, math.Min(1, t))
+	return point.Distance(Point2D{X: a.X + t*(b.X-a.X), Y: a.Y + t*(b.Y-a.Y)})
+}
+
 func (point Point2D) Azimuth(to Point2D) float64 {
 	return math.Atan2(to.X-point.X, to.Y-point.Y)
 }
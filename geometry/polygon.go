@@ -153,6 +153,18 @@ func (poly Polygon2D) Bounds() Bounds {
 	return Bounds{MinX: minX, MinY: minY, MaxX: maxX, MaxY: maxY}
 }
 
+func (multipoly Multipolygon2D) Bounds() Bounds {
+	res := Bounds{MinX: math.MaxFloat64, MinY: math.MaxFloat64, MaxX: -math.MaxFloat64, MaxY: -math.MaxFloat64}
+	for _, p := range multipoly.Polygons {
+		b := p.Bounds()
+		res.MinX = math.Min(res.MinX, b.MinX)
+		res.MinY = math.Min(res.MinY, b.MinY)
+		res.MaxX = math.Max(res.MaxX, b.MaxX)
+		res.MaxY = math.Max(res.MaxY, b.MaxY)
+	}
+	return res
+}
+
 func (poly Polygon2D) Azimuths() []float64 {
 	res := make([]float64, 0)
 	for i := 0; i < len(poly.Polygon); i++ {
@@ -289,7 +301,7 @@ func segmentIntersection(a1 Point2D, b1 Point2D, a2 Point2D, b2 Point2D) bool {
 	return false
 }
 
-func isLineStringInLineString(polyA []Point2D, polyB []Point2D) bool {
+func isLineStringsCrossing(polyA []Point2D, polyB []Point2D) bool {
 	iA := 0
 	nA := len(polyA)
 	nB := len(polyB)
@@ -305,13 +317,20 @@ func isLineStringInLineString(polyA []Point2D, polyB []Point2D) bool {
 			aB := bB
 			bB = polyB[iB]
 			if segmentIntersection(aA, bA, aB, bB) {
-				return false
+				return true
 			}
 			iB++
 		}
 
 		iA++
 	}
+	return false
+}
+
+func isLineStringInLineString(polyA []Point2D, polyB []Point2D) bool {
+	if isLineStringsCrossing(polyA, polyB) {
+		return false
+	}
 	return containsPoint(polyA[0], polyB)
 }
 
@@ -330,6 +349,46 @@ func (polygon Polygon2D) Contains(dst Polygon2D) bool {
 	return true
 }
 
+// ContainsWithHoles is same as Contains, but also checks that holes are completely outside of
+// destination polygon
+func (polygon Polygon2D) ContainsWithHoles(dst Polygon2D) bool {
+	if !polygon.Contains(dst) {
+		return false
+	}
+	for _, h := range polygon.Holes {
+		if len(h) == 0 {
+			continue
+		}
+		if isLineStringsCrossing(h, dst.Polygon) || containsPoint(h[0], dst.Polygon) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsSimple checks that rings of a polygon doesn't cross themselves and each other
+func (poly Polygon2D) IsSimple() bool {
+	rings := append([]LineString2D{poly.Polygon}, poly.Holes...)
+	for r1, ring1 := range rings {
+		for i := range ring1 {
+			a1 := ring1[i]
+			b1 := ring1[(i+1)%len(ring1)]
+			for r2 := r1; r2 < len(rings); r2++ {
+				ring2 := rings[r2]
+				for j := range ring2 {
+					if r1 == r2 && (j <= i+1 || (i == 0 && j == len(ring1)-1)) {
+						continue
+					}
+					if segmentIntersection(a1, b1, ring2[j], ring2[(j+1)%len(ring2)]) {
+						return false
+					}
+				}
+			}
+		}
+	}
+	return true
+}
+
 func (poly Polygon2D) RayIntersections(origin Point2D, dx float64, dy float64) (*Point2D, *Point2D) {
 	shiftedOrigin := Point2D{X: origin.X + dx, Y: origin.Y + dy}
 	useX := true
@@ -342,7 +401,13 @@ func (poly Polygon2D) RayIntersections(origin Point2D, dx float64, dy float64) (
 	var closestPointRight *Point2D
 	// fmt.Println("Search Intersections")
 	// fmt.Println("- Line: " + origin.DebugString() + " - " + shiftedOrigin.DebugString())
-	for _, e := range poly.Edges() {
+	edges := poly.Edges()
+	for _, h := range poly.Holes {
+		for i := 0; i < len(h); i++ {
+			edges = append(edges, Edge2D{Start: h[i], End: h[(i+1)%len(h)]})
+		}
+	}
+	for _, e := range edges {
 		// fmt.Println("- Edge: " + e.Start.DebugString() + " - " + e.End.DebugString())
 		hasP, p := lineIntersection(origin, shiftedOrigin, e.Start, e.End)
 		if hasP && pointInSegmentBox(p, e.Start, e.End) {
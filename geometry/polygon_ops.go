@@ -1,5 +1,7 @@
 package geometry
 
+import "math"
+
 func (a Bounds) Intersects(b Bounds) bool {
 	// https://stackoverflow.com/questions/306316/determine-if-two-rectangles-overlap-each-other
 	// RectA.X1 < RectB.X2 && RectA.X2 > RectB.X1 && RectA.Y1 > RectB.Y2 && RectA.Y2 < RectB.Y1
@@ -48,3 +50,58 @@ func (a Multipolygon2D) Intersects(b Multipolygon2D) bool {
 	}
 	return false
 }
+
+// OverlapResolution is a number of sample points along longest side of a shape when measuring overlap
+const OverlapResolution = 32
+
+func (a Multipolygon2D) ContainsPoint(point Point2D) bool {
+	for _, p := range a.Polygons {
+		if p.ContainsPoint(point) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a Multipolygon2D) Area() float64 {
+	res := 0.0
+	for _, p := range a.Polygons {
+		res += p.Area()
+	}
+	return res
+}
+
+// Overlap estimates a share of the smaller shape that is covered by the larger one by sampling points on a grid
+func (a Multipolygon2D) Overlap(b Multipolygon2D) float64 {
+	if !a.Bounds().Intersects(b.Bounds()) {
+		return 0
+	}
+	small := a
+	large := b
+	if b.Area() < a.Area() {
+		small = b
+		large = a
+	}
+	bounds := small.Bounds()
+	cell := math.Max(bounds.MaxX-bounds.MinX, bounds.MaxY-bounds.MinY) / OverlapResolution
+	if cell < eps {
+		return 0
+	}
+	inside := 0
+	covered := 0
+	for x := bounds.MinX + cell/2; x < bounds.MaxX; x += cell {
+		for y := bounds.MinY + cell/2; y < bounds.MaxY; y += cell {
+			p := Point2D{X: x, Y: y}
+			if small.ContainsPoint(p) {
+				inside++
+				if large.ContainsPoint(p) {
+					covered++
+				}
+			}
+		}
+	}
+	if inside == 0 {
+		return 0
+	}
+	return float64(covered) / float64(inside)
+}
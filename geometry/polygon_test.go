@@ -255,3 +255,36 @@ func TestComplexPolyIntersections(t *testing.T) {
 	// 	{-73.946961,40.698641}
 	// })
 }
+
+func TestPolyInPolyWithHoles(t *testing.T) {
+	courtyard := Polygon2D{Polygon: []Point2D{{0, 0}, {30, 0}, {30, 30}, {0, 30}}, Holes: [][]Point2D{{{10, 10}, {20, 10}, {20, 20}, {10, 20}}}}
+
+	// Footprint in solid part of a polygon
+	assert.True(t, courtyard.ContainsWithHoles(NewSimplePolygon([]Point2D{{1, 1}, {9, 1}, {9, 5}, {1, 5}})))
+
+	// Footprint overlapping hole
+	assert.False(t, courtyard.ContainsWithHoles(NewSimplePolygon([]Point2D{{5, 5}, {15, 5}, {15, 8}, {5, 8}}).Shift(Point2D{X: 0, Y: 7})))
+
+	// Footprint that surrounds hole completely
+	assert.False(t, courtyard.ContainsWithHoles(NewSimplePolygon([]Point2D{{5, 5}, {25, 5}, {25, 25}, {5, 25}})))
+}
+
+func TestIsSimple(t *testing.T) {
+	assert.True(t, NewSimplePolygon([]Point2D{{0, 0}, {10, 0}, {10, 10}, {0, 10}}).IsSimple())
+	assert.False(t, NewSimplePolygon([]Point2D{{0, 0}, {10, 10}, {10, 0}, {0, 10}}).IsSimple())
+	withHole := Polygon2D{Polygon: []Point2D{{0, 0}, {10, 0}, {10, 10}, {0, 10}}, Holes: [][]Point2D{{{4, 4}, {6, 4}, {6, 6}, {4, 6}}}}
+	assert.True(t, withHole.IsSimple())
+	withHole.Holes[0][1] = Point2D{12, 4}
+	assert.False(t, withHole.IsSimple())
+}
+
+func TestOverlap(t *testing.T) {
+	square := func(x float64, y float64, size float64) Multipolygon2D {
+		return Multipolygon2D{Polygons: []Polygon2D{NewSimplePolygon([]Point2D{{x, y}, {x + size, y}, {x + size, y + size}, {x, y + size}})}}
+	}
+	parent := square(0, 0, 10)
+	assert.InDelta(t, 1, parent.Overlap(square(0, 0, 5)), 0.01)
+	assert.InDelta(t, 1, square(5, 5, 5).Overlap(parent), 0.01)
+	assert.InDelta(t, 0.5, parent.Overlap(square(7.5, 0, 5)), 0.05)
+	assert.InDelta(t, 0, parent.Overlap(square(20, 20, 5)), 0.01)
+}
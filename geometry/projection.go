@@ -4,9 +4,19 @@ import (
 	"math"
 )
 
+// MaxProjectionDistortion is a default maximum scale error of a projection
+const MaxProjectionDistortion = 0.001
+
+// Projection is a gnomonic projection to a plane that touches sphere at center
+// or a transverse mercator projection of a local UTM zone
 type Projection struct {
 	center Point3D
 
+	utm             bool
+	zone            int
+	centralMeridian float64
+	origin          Point2D
+
 	cosLon  float64
 	sinLon  float64
 	cosNLon float64
@@ -47,11 +57,59 @@ func NewProjection(center PointGeo) *Projection {
 	}
 }
 
+// PickProjection selects projection for a dataset: gnomonic if it's distortion is acceptable
+// for given bounds and local UTM zone otherwise
+func PickProjection(bounds BoundsGeo, maxDistortion float64) *Projection {
+	center := PointGeo{Latitude: (bounds.MinLatitude + bounds.MaxLatitude) / 2, Longitude: (bounds.MinLongitude + bounds.MaxLongitude) / 2}
+	distance := 0.0
+	for _, corner := range []PointGeo{
+		{Latitude: bounds.MinLatitude, Longitude: bounds.MinLongitude},
+		{Latitude: bounds.MinLatitude, Longitude: bounds.MaxLongitude},
+		{Latitude: bounds.MaxLatitude, Longitude: bounds.MinLongitude},
+		{Latitude: bounds.MaxLatitude, Longitude: bounds.MaxLongitude},
+	} {
+		distance = math.Max(distance, center.DistanceTo(corner))
+	}
+	res := NewProjection(center)
+	if res.Distortion(distance) <= maxDistortion {
+		return res
+	}
+	return NewUTMProjection(center)
+}
+
+// IsUTM checks if projection is a transverse mercator of a UTM zone
+func (proj *Projection) IsUTM() bool {
+	return proj.utm
+}
+
+// Zone returns UTM zone of projection or zero for gnomonic ones
+func (proj *Projection) Zone() int {
+	return proj.zone
+}
+
+// Distortion returns maximum relative scale error at distance (in meters) from center of projection
+func (proj *Projection) Distortion(distance float64) float64 {
+	if proj.utm {
+		x := proj.origin.X
+		return math.Max(math.Abs(utmScale(math.Abs(x)+distance)-1), math.Abs(utmK0-1))
+	}
+
+	// Gnomonic projection is stretched by 1/cos^2 along radius and by 1/cos across it
+	angle := distance / WORLD_RADIUS
+	if angle >= math.Pi/2 {
+		return math.Inf(1)
+	}
+	return 1/(math.Cos(angle)*math.Cos(angle)) - 1
+}
+
 //
 // Point Projection
 //
 
 func (point PointGeo) Project(proj *Projection) Point2D {
+	if proj.utm {
+		return point.projectUTM(proj)
+	}
 
 	//
 	// Projecting of point to a projection plane
@@ -88,6 +146,9 @@ func (point PointGeo) Project(proj *Projection) Point2D {
 }
 
 func (point Point2D) Unproject(proj *Projection) PointGeo {
+	if proj.utm {
+		return point.unprojectUTM(proj)
+	}
 
 	//
 	// Rotate coorinates back
@@ -138,7 +199,7 @@ func (poly Polygon2D) Unproject(proj *Projection) PolygonGeo {
 	// Main String
 	main := make(LineStringGeo, 0)
 	for i := 0; i < len(poly.Polygon); i++ {
-		main = append(main, poly.Polygon[0].Unproject(proj))
+		main = append(main, poly.Polygon[i].Unproject(proj))
 	}
 	res = append(res, main)
 
@@ -146,7 +207,7 @@ func (poly Polygon2D) Unproject(proj *Projection) PolygonGeo {
 	for i := 0; i < len(poly.Holes); i++ {
 		hole := make(LineStringGeo, 0)
 		for j := 0; j < len(poly.Holes[i]); j++ {
-			hole = append(main, poly.Holes[i][j].Unproject(proj))
+			hole = append(hole, poly.Holes[i][j].Unproject(proj))
 		}
 		res = append(res, hole)
 
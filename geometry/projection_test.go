@@ -3,6 +3,7 @@ package geometry
 import (
 	"math"
 	"testing"
+	"testing/quick"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -79,3 +80,106 @@ func TestPointProjection(t *testing.T) {
 	assert.InEpsilon(t, -73.995979, point2u.Longitude, 0.000001)
 	assert.InEpsilon(t, 40.722887, point2u.Latitude, 0.000001)
 }
+
+// randomPoint maps arbitrary values to a point around center within radius degrees
+func randomPoint(center PointGeo, radius float64, a float64, b float64) PointGeo {
+	return PointGeo{
+		Longitude: center.Longitude + radius*math.Sin(a),
+		Latitude:  center.Latitude + radius*math.Sin(b),
+	}
+}
+
+func randomRing(center PointGeo, radius float64, seed float64, count int) []PointGeo {
+	res := make([]PointGeo, 0)
+	for i := 0; i < count; i++ {
+		res = append(res, randomPoint(center, radius, seed*float64(i+1), seed*float64(i+7)))
+	}
+	return res
+}
+
+func assertRingsEqual(t *testing.T, expected []PointGeo, actual []PointGeo) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+	for i := range expected {
+		if math.Abs(expected[i].Latitude-actual[i].Latitude) > 1e-7 || math.Abs(expected[i].Longitude-actual[i].Longitude) > 1e-7 {
+			t.Logf("Expected %v, got %v", expected[i], actual[i])
+			return false
+		}
+	}
+	return true
+}
+
+func projections(center PointGeo) []*Projection {
+	return []*Projection{NewProjection(center), NewUTMProjection(center)}
+}
+
+func TestPointRoundTrip(t *testing.T) {
+	f := func(lon float64, lat float64, a float64, b float64) bool {
+		center := PointGeo{Longitude: 179 * math.Sin(lon), Latitude: 80 * math.Sin(lat)}
+		p := randomPoint(center, 0.5, a, b)
+		for _, proj := range projections(center) {
+			if !assertRingsEqual(t, []PointGeo{p}, []PointGeo{p.Project(proj).Unproject(proj)}) {
+				return false
+			}
+		}
+		return true
+	}
+	assert.NoError(t, quick.Check(f, nil))
+}
+
+func TestPolygonRoundTrip(t *testing.T) {
+	f := func(lon float64, lat float64, seed float64, holes uint8) bool {
+		center := PointGeo{Longitude: 179 * math.Sin(lon), Latitude: 80 * math.Sin(lat)}
+		poly := PolygonGeo{LineStrings: []LineStringGeo{randomRing(center, 0.01, seed, 6)}}
+		for h := 0; h < int(holes%3); h++ {
+			poly.LineStrings = append(poly.LineStrings, randomRing(center, 0.001, seed+float64(h), 4))
+		}
+		multi := MultipolygonGeo{Polygons: []PolygonGeo{poly, {LineStrings: []LineStringGeo{randomRing(center, 0.01, seed*2, 5)}}}}
+		for _, proj := range projections(center) {
+			res := multi.Project(proj).Unproject(proj)
+			if len(res.Polygons) != len(multi.Polygons) {
+				return false
+			}
+			for i := range multi.Polygons {
+				if len(res.Polygons[i].LineStrings) != len(multi.Polygons[i].LineStrings) {
+					return false
+				}
+				for j := range multi.Polygons[i].LineStrings {
+					if !assertRingsEqual(t, multi.Polygons[i].LineStrings[j], res.Polygons[i].LineStrings[j]) {
+						return false
+					}
+				}
+			}
+		}
+		return true
+	}
+	assert.NoError(t, quick.Check(f, nil))
+}
+
+func TestUTMProjection(t *testing.T) {
+	// Distances are preserved with UTM scale
+	center := PointGeo{-73.996005, 40.722822}
+	proj := NewUTMProjection(center)
+	assert.Equal(t, 18, proj.Zone())
+	assert.True(t, proj.IsUTM())
+	assert.InDelta(t, 0, center.Project(proj).X, 0.000001)
+	a := PointGeo{-73.996005, 40.722822}.Project(proj)
+	b := PointGeo{-73.995979, 40.722887}.Project(proj)
+	assert.InDelta(t, 7.5524, a.Distance(b), 0.01)
+}
+
+func TestDistortion(t *testing.T) {
+	proj := NewProjection(PointGeo{-73.996005, 40.722822})
+	assert.Equal(t, 0.0, proj.Distortion(0))
+	assert.InDelta(t, 0.0000025, proj.Distortion(10000), 0.0000001)
+	assert.True(t, proj.Distortion(300000) > 0.001)
+
+	// Small datasets use gnomonic projection, large ones local UTM zone
+	city := BoundsGeo{MinLongitude: -74.1, MinLatitude: 40.5, MaxLongitude: -73.7, MaxLatitude: 40.9}
+	assert.False(t, PickProjection(city, MaxProjectionDistortion).IsUTM())
+	state := BoundsGeo{MinLongitude: -79.7, MinLatitude: 40.5, MaxLongitude: -71.8, MaxLatitude: 45}
+	large := PickProjection(state, MaxProjectionDistortion)
+	assert.True(t, large.IsUTM())
+	assert.Equal(t, 18, large.Zone())
+}
@@ -0,0 +1,92 @@
+package geometry
+
+import "math"
+
+// WGS84 ellipsoid
+const wgs84A = 6378137.0
+const wgs84F = 1 / 298.257223563
+
+// UTM scale factor on central meridian
+const utmK0 = 0.9996
+
+// Coefficients of Krüger series for transverse mercator
+var utmN = wgs84F / (2 - wgs84F)
+var utmA = wgs84A / (1 + utmN) * (1 + utmN*utmN/4 + utmN*utmN*utmN*utmN/64)
+var utmAlpha = [3]float64{
+	utmN/2 - 2*utmN*utmN/3 + 5*utmN*utmN*utmN/16,
+	13*utmN*utmN/48 - 3*utmN*utmN*utmN/5,
+	61 * utmN * utmN * utmN / 240,
+}
+var utmBeta = [3]float64{
+	utmN/2 - 2*utmN*utmN/3 + 37*utmN*utmN*utmN/96,
+	utmN*utmN/48 + utmN*utmN*utmN/15,
+	17 * utmN * utmN * utmN / 480,
+}
+var utmDelta = [3]float64{
+	2*utmN - 2*utmN*utmN/3 - 2*utmN*utmN*utmN,
+	7*utmN*utmN/3 - 8*utmN*utmN*utmN/5,
+	56 * utmN * utmN * utmN / 15,
+}
+
+// UTMZone returns UTM zone number of a point
+func UTMZone(point PointGeo) int {
+	zone := int(math.Floor((point.Longitude+180)/6)) + 1
+	if zone > 60 {
+		zone = 60
+	}
+	if zone < 1 {
+		zone = 1
+	}
+	return zone
+}
+
+// NewUTMProjection creates transverse mercator projection of UTM zone of a center.
+// Coordinates are shifted to keep center at origin.
+func NewUTMProjection(center PointGeo) *Projection {
+	zone := UTMZone(center)
+	res := &Projection{utm: true, zone: zone, centralMeridian: float64(zone-1)*6 - 180 + 3}
+	res.origin = center.projectUTM(res)
+	return res
+}
+
+// projectUTM calculates easting and northing (without false easting and northing)
+func (point PointGeo) projectUTM(proj *Projection) Point2D {
+	lat := rad(point.Latitude)
+	lon := rad(point.Longitude - proj.centralMeridian)
+	c := 2 * math.Sqrt(utmN) / (1 + utmN)
+	t := math.Sinh(math.Atanh(math.Sin(lat)) - c*math.Atanh(c*math.Sin(lat)))
+	xi := math.Atan2(t, math.Cos(lon))
+	eta := math.Atanh(math.Sin(lon) / math.Sqrt(1+t*t))
+	x := eta
+	y := xi
+	for j := 0; j < 3; j++ {
+		k := 2 * float64(j+1)
+		x += utmAlpha[j] * math.Cos(k*xi) * math.Sinh(k*eta)
+		y += utmAlpha[j] * math.Sin(k*xi) * math.Cosh(k*eta)
+	}
+	return Point2D{X: utmK0*utmA*x - proj.origin.X, Y: utmK0*utmA*y - proj.origin.Y}
+}
+
+func (point Point2D) unprojectUTM(proj *Projection) PointGeo {
+	xi := (point.Y + proj.origin.Y) / (utmK0 * utmA)
+	eta := (point.X + proj.origin.X) / (utmK0 * utmA)
+	xi1 := xi
+	eta1 := eta
+	for j := 0; j < 3; j++ {
+		k := 2 * float64(j+1)
+		xi1 -= utmBeta[j] * math.Sin(k*xi) * math.Cosh(k*eta)
+		eta1 -= utmBeta[j] * math.Cos(k*xi) * math.Sinh(k*eta)
+	}
+	chi := math.Asin(math.Sin(xi1) / math.Cosh(eta1))
+	lat := chi
+	for j := 0; j < 3; j++ {
+		lat += utmDelta[j] * math.Sin(2*float64(j+1)*chi)
+	}
+	lon := math.Atan2(math.Sinh(eta1), math.Cos(xi1))
+	return PointGeo{Longitude: proj.centralMeridian + grad(lon), Latitude: grad(lat)}
+}
+
+// utmScale is an approximate scale factor at distance x from central meridian
+func utmScale(x float64) float64 {
+	return utmK0 * (1 + x*x/(2*wgs84A*wgs84A))
+}
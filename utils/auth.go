@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenSource provides bearer tokens for API requests
+type TokenSource interface {
+	// Token returns current token
+	Token() (string, error)
+	// Invalidate is called when server rejects a token
+	Invalidate()
+}
+
+// StaticToken is a token that never changes
+type StaticToken string
+
+func (t StaticToken) Token() (string, error) {
+	return string(t), nil
+}
+
+func (t StaticToken) Invalidate() {
+}
+
+// LoadTokenFile reads token from a file ignoring surrounding whitespace
+func LoadTokenFile(src string) (StaticToken, error) {
+	data, e := ioutil.ReadFile(src)
+	if e != nil {
+		return "", e
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", errors.New("Token file " + src + " is empty")
+	}
+	return StaticToken(token), nil
+}
+
+// TokenRefreshMargin is how long before expiration token is refreshed
+const TokenRefreshMargin = 30 * time.Second
+
+// ClientCredentials fetches tokens with OAuth client credentials grant and caches them until expiration
+type ClientCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scope        string
+	Client       *http.Client
+
+	lock    sync.Mutex
+	token   string
+	expires time.Time
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+func (c *ClientCredentials) Token() (string, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.token != "" && (c.expires.IsZero() || time.Now().Add(TokenRefreshMargin).Before(c.expires)) {
+		return c.token, nil
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", c.ClientID)
+	form.Set("client_secret", c.ClientSecret)
+	if c.Scope != "" {
+		form.Set("scope", c.Scope)
+	}
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, e := client.PostForm(c.TokenURL, form)
+	if e != nil {
+		return "", e
+	}
+	defer response.Body.Close()
+	body, e := ioutil.ReadAll(response.Body)
+	if e != nil {
+		return "", e
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Unable to get token: %s", response.Status)
+	}
+	var res tokenResponse
+	e = json.Unmarshal(body, &res)
+	if e != nil {
+		return "", e
+	}
+	if res.AccessToken == "" {
+		return "", errors.New("Token response doesn't have access token")
+	}
+	c.token = res.AccessToken
+	c.expires = time.Time{}
+	if res.ExpiresIn > 0 {
+		c.expires = time.Now().Add(time.Duration(res.ExpiresIn) * time.Second)
+	}
+	return c.token, nil
+}
+
+func (c *ClientCredentials) Invalidate() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.token = ""
+}
+
+// ParseHeader parses header in "Name: value" format
+func ParseHeader(src string) (string, string, error) {
+	parts := strings.SplitN(src, ":", 2)
+	if len(parts) < 2 || strings.TrimSpace(parts[0]) == "" {
+		return "", "", errors.New("Header should be in 'Name: value' format: " + src)
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), nil
+}
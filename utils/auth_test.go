@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientCredentials(t *testing.T) {
+	var issued int32
+	tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("grant_type") != "client_credentials" || r.Form.Get("client_id") != "id" || r.Form.Get("client_secret") != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		n := atomic.AddInt32(&issued, 1)
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer","expires_in":3600}`, n)
+	}))
+	defer tokens.Close()
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// First token is treated as revoked
+		if r.Header.Get("Authorization") != "Bearer token-2" || r.Header.Get("X-City") != "sf" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"data":{"ok":true}}`)
+	}))
+	defer api.Close()
+
+	credentials := &ClientCredentials{TokenURL: tokens.URL, ClientID: "id", ClientSecret: "secret"}
+	client := NewGraphQLClient(api.URL, time.Second)
+	client.Token = credentials
+	client.Headers["X-City"] = "sf"
+	res, e := client.Request("query { ok }", map[string]interface{}{})
+	if e != nil {
+		t.Fatal(e)
+	}
+	if res != `{"ok":true}` {
+		t.Errorf("Unexpected response %s", res)
+	}
+
+	// Cached token is reused
+	_, e = client.Request("query { ok }", map[string]interface{}{})
+	if e != nil {
+		t.Fatal(e)
+	}
+	if atomic.LoadInt32(&issued) != 2 {
+		t.Errorf("Expected 2 issued tokens, got %d", issued)
+	}
+
+	// Unauthorized without token source
+	_, e = NewGraphQLClient(api.URL, time.Second).Request("query { ok }", map[string]interface{}{})
+	if e == nil {
+		t.Error("Expected unauthorized error")
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	name, value, e := ParseHeader("X-Api-Key: a:b ")
+	if e != nil || name != "X-Api-Key" || value != "a:b" {
+		t.Errorf("Unexpected header %s=%s %v", name, value, e)
+	}
+	_, _, e = ParseHeader("broken")
+	if e == nil {
+		t.Error("Expected error")
+	}
+}
@@ -0,0 +1,206 @@
+package utils
+
+import (
+	"errors"
+	"math"
+)
+
+// Geometry comparison modes
+const (
+	// GeometryExact compares coordinates with exact equality
+	GeometryExact = "exact"
+	// GeometryNormalized ignores ring start point and orientation and compares coordinates with tolerance
+	GeometryNormalized = "normalized"
+	// GeometryHausdorff compares rings by Hausdorff distance
+	GeometryHausdorff = "hausdorff"
+)
+
+const metersPerDegree = 111319.49
+
+// GeometryComparison configures how geometry changes are detected. Tolerance is in meters.
+type GeometryComparison struct {
+	Mode      string
+	Tolerance float64
+}
+
+// ExactComparison is a default strict geometry comparison
+var ExactComparison = GeometryComparison{Mode: GeometryExact}
+
+// NewGeometryComparison validates mode and tolerance
+func NewGeometryComparison(mode string, tolerance float64) (GeometryComparison, error) {
+	if mode == "" {
+		mode = GeometryExact
+	}
+	if mode != GeometryExact && mode != GeometryNormalized && mode != GeometryHausdorff {
+		return GeometryComparison{}, errors.New("Unknown geometry comparison mode " + mode)
+	}
+	if tolerance < 0 {
+		return GeometryComparison{}, errors.New("Tolerance should not be negative")
+	}
+	return GeometryComparison{Mode: mode, Tolerance: tolerance}, nil
+}
+
+// IsChanged checks if geometry is changed according to comparison mode
+func (c GeometryComparison) IsChanged(coords1 []interface{}, coords2 []interface{}) (bool, error) {
+	if c.Mode == "" || c.Mode == GeometryExact {
+		return IsGeometryChanged(coords1, coords2), nil
+	}
+	polys1, e := parseRings(coords1)
+	if e != nil {
+		return true, e
+	}
+	polys2, e := parseRings(coords2)
+	if e != nil {
+		return true, e
+	}
+	if len(polys1) != len(polys2) {
+		return true, nil
+	}
+	for i := range polys1 {
+		if len(polys1[i]) != len(polys2[i]) {
+			return true, nil
+		}
+		for j := range polys1[i] {
+			var same bool
+			if c.Mode == GeometryHausdorff {
+				same = hausdorffDistance(polys1[i][j], polys2[i][j]) <= c.Tolerance
+			} else {
+				same = isRingSame(normalizeRing(polys1[i][j]), normalizeRing(polys2[i][j]), c.Tolerance)
+			}
+			if !same {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func parseRings(coords []interface{}) ([][][][]float64, error) {
+	res := make([][][][]float64, len(coords))
+	for i, p := range coords {
+		poly, ok := p.([]interface{})
+		if !ok {
+			return nil, errors.New("Polygon is not an array")
+		}
+		res[i] = make([][][]float64, len(poly))
+		for j, l := range poly {
+			line, ok := l.([]interface{})
+			if !ok {
+				return nil, errors.New("Ring is not an array")
+			}
+			res[i][j] = make([][]float64, len(line))
+			for k, pt := range line {
+				point, ok := pt.([]interface{})
+				if !ok || len(point) < 2 {
+					return nil, errors.New("Point is not a coordinate pair")
+				}
+				x, okX := point[0].(float64)
+				y, okY := point[1].(float64)
+				if !okX || !okY {
+					return nil, errors.New("Point has non-numeric coordinates")
+				}
+				res[i][j][k] = []float64{x, y}
+			}
+		}
+	}
+	return res, nil
+}
+
+// normalizeRing removes closing point and makes ring counter clockwise
+func normalizeRing(ring [][]float64) [][]float64 {
+	if len(ring) > 1 && ring[0][0] == ring[len(ring)-1][0] && ring[0][1] == ring[len(ring)-1][1] {
+		ring = ring[:len(ring)-1]
+	}
+	area := 0.0
+	for i := range ring {
+		a := ring[i]
+		b := ring[(i+1)%len(ring)]
+		area += a[0]*b[1] - b[0]*a[1]
+	}
+	if area >= 0 {
+		return ring
+	}
+	res := make([][]float64, len(ring))
+	for i := range ring {
+		res[i] = ring[len(ring)-1-i]
+	}
+	return res
+}
+
+// pointDistance is an approximate distance between two points in meters
+func pointDistance(a []float64, b []float64) float64 {
+	lat := (a[1] + b[1]) / 2 * math.Pi / 180
+	dx := (a[0] - b[0]) * math.Cos(lat) * metersPerDegree
+	dy := (a[1] - b[1]) * metersPerDegree
+	return math.Sqrt(dx*dx + dy*dy)
+}
+
+// segmentDistance is an approximate distance from a point to a segment in meters
+func segmentDistance(p []float64, a []float64, b []float64) float64 {
+	scale := math.Cos(a[1]*math.Pi/180) * metersPerDegree
+	px := (p[0] - a[0]) * scale
+	py := (p[1] - a[1]) * metersPerDegree
+	bx := (b[0] - a[0]) * scale
+	by := (b[1] - a[1]) * metersPerDegree
+	l := bx*bx + by*by
+	t := 0.0
+	if l > 0 {
+		t = math.Max(0, math.Min(1, (px*bx+py*by)/l))
+	}
+	dx := px - t*bx
+	dy := py - t*by
+	return math.Sqrt(dx*dx + dy*dy)
+}
+
+// isRingSame compares normalized rings starting from a vertex closest to a first point of a
+func isRingSame(a [][]float64, b [][]float64, tolerance float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	if len(a) == 0 {
+		return true
+	}
+	for offset := range b {
+		if pointDistance(a[0], b[offset]) > tolerance {
+			continue
+		}
+		same := true
+		for i := range a {
+			if pointDistance(a[i], b[(i+offset)%len(b)]) > tolerance {
+				same = false
+				break
+			}
+		}
+		if same {
+			return true
+		}
+	}
+	return false
+}
+
+// ringDistance is a largest distance from a vertex of a to edges of b
+func ringDistance(a [][]float64, b [][]float64) float64 {
+	res := 0.0
+	for _, p := range a {
+		best := math.Inf(1)
+		if len(b) == 1 {
+			best = pointDistance(p, b[0])
+		}
+		for i := 0; i+1 < len(b); i++ {
+			best = math.Min(best, segmentDistance(p, b[i], b[i+1]))
+		}
+		res = math.Max(res, best)
+	}
+	return res
+}
+
+// hausdorffDistance is a symmetric Hausdorff distance between two closed rings
+func hausdorffDistance(a [][]float64, b [][]float64) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		if len(a) == len(b) {
+			return 0
+		}
+		return math.Inf(1)
+	}
+	return math.Max(ringDistance(a, b), ringDistance(b, a))
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func parseCoords(t *testing.T, src string) []interface{} {
+	var res []interface{}
+	e := json.Unmarshal([]byte(src), &res)
+	if e != nil {
+		t.Fatal(e)
+	}
+	return res
+}
+
+func TestGeometryComparison(t *testing.T) {
+	square := parseCoords(t, `[[[[0,0],[0.001,0],[0.001,0.001],[0,0.001],[0,0]]]]`)
+	shifted := parseCoords(t, `[[[[0.001,0],[0.001,0.001],[0,0.001],[0,0],[0.001,0]]]]`)
+	reversed := parseCoords(t, `[[[[0,0],[0,0.001],[0.001,0.001],[0.001,0],[0,0]]]]`)
+	precision := parseCoords(t, `[[[[0.000000000001,0],[0.001,0],[0.001,0.001],[0,0.001],[0.000000000001,0]]]]`)
+	extraVertex := parseCoords(t, `[[[[0,0],[0.0005,0],[0.001,0],[0.001,0.001],[0,0.001],[0,0]]]]`)
+	moved := parseCoords(t, `[[[[0,0],[0.002,0],[0.001,0.001],[0,0.001],[0,0]]]]`)
+
+	exact := ExactComparison
+	normalized, _ := NewGeometryComparison(GeometryNormalized, 0.01)
+	hausdorff, _ := NewGeometryComparison(GeometryHausdorff, 0.01)
+
+	cases := []struct {
+		name       string
+		other      []interface{}
+		exact      bool
+		normalized bool
+		hausdorff  bool
+	}{
+		{"same", square, false, false, false},
+		{"shifted", shifted, true, false, false},
+		{"reversed", reversed, true, false, false},
+		{"precision", precision, true, false, false},
+		{"extra vertex", extraVertex, true, true, false},
+		{"moved", moved, true, true, true},
+	}
+	for _, c := range cases {
+		if r, _ := exact.IsChanged(square, c.other); r != c.exact {
+			t.Errorf("%s: exact comparison returned %v", c.name, r)
+		}
+		if r, _ := normalized.IsChanged(square, c.other); r != c.normalized {
+			t.Errorf("%s: normalized comparison returned %v", c.name, r)
+		}
+		if r, _ := hausdorff.IsChanged(square, c.other); r != c.hausdorff {
+			t.Errorf("%s: hausdorff comparison returned %v", c.name, r)
+		}
+	}
+
+	// Malformed geometry is an error instead of panic
+	malformed := []interface{}{[]interface{}{[]interface{}{"a"}}}
+	if _, e := normalized.IsChanged(square, malformed); e == nil {
+		t.Error("Expected error for malformed geometry")
+	}
+	if _, e := hausdorff.IsChanged(malformed, square); e == nil {
+		t.Error("Expected error for malformed geometry")
+	}
+
+	_, e := NewGeometryComparison("unknown", 0)
+	if e == nil {
+		t.Error("Expected error for unknown mode")
+	}
+}
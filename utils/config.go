@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ConfigFileName is a name of config file in home and project directories
+const ConfigFileName = ".borg.yaml"
+
+// OAuthProfile is OAuth client credentials configuration
+type OAuthProfile struct {
+	TokenURL     string `yaml:"token_url"`
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret"`
+	Scope        string `yaml:"scope"`
+}
+
+// StorageProfile configures where datasets are synced
+type StorageProfile struct {
+	Backend string `yaml:"backend"`
+	Bucket  string `yaml:"bucket"`
+}
+
+// MapboxProfile is Mapbox credentials
+type MapboxProfile struct {
+	Token string `yaml:"token"`
+	User  string `yaml:"user"`
+}
+
+// Profile is a named set of defaults for commands
+type Profile struct {
+	Server     string            `yaml:"server"`
+	Token      string            `yaml:"token"`
+	TokenFile  string            `yaml:"token_file"`
+	OAuth      OAuthProfile      `yaml:"oauth"`
+	Headers    map[string]string `yaml:"headers"`
+	Timeout    string            `yaml:"timeout"`
+	Queries    string            `yaml:"queries"`
+	Batch      int               `yaml:"batch"`
+	BatchBytes int               `yaml:"batch_bytes"`
+	Storage    StorageProfile    `yaml:"storage"`
+	Mapbox     MapboxProfile     `yaml:"mapbox"`
+}
+
+// Config is a content of config files
+type Config struct {
+	DefaultProfile string              `yaml:"default_profile"`
+	Profiles       map[string]*Profile `yaml:"profiles"`
+}
+
+// TimeoutDuration parses timeout of a profile
+func (p *Profile) TimeoutDuration() (time.Duration, error) {
+	if p.Timeout == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(p.Timeout)
+}
+
+// ConfigPaths returns config files in order of loading: home directory first, then project directory
+func ConfigPaths() []string {
+	res := make([]string, 0)
+	if home, e := os.UserHomeDir(); e == nil {
+		res = append(res, filepath.Join(home, ConfigFileName))
+	}
+	if wd, e := os.Getwd(); e == nil {
+		local := filepath.Join(wd, ConfigFileName)
+		if len(res) == 0 || res[0] != local {
+			res = append(res, local)
+		}
+	}
+	return res
+}
+
+// LoadConfig reads all existing config files, profiles of later files replace profiles with the same name
+func LoadConfig(paths []string) (*Config, error) {
+	res := &Config{Profiles: make(map[string]*Profile)}
+	for _, p := range paths {
+		if !FileExists(p) {
+			continue
+		}
+		data, e := ioutil.ReadFile(p)
+		if e != nil {
+			return nil, e
+		}
+		var cfg Config
+		e = yaml.UnmarshalStrict(data, &cfg)
+		if e != nil {
+			return nil, errors.New("Unable to read " + p + ": " + e.Error())
+		}
+		if cfg.DefaultProfile != "" {
+			res.DefaultProfile = cfg.DefaultProfile
+		}
+		for name, profile := range cfg.Profiles {
+			if profile == nil {
+				profile = &Profile{}
+			}
+			res.Profiles[name] = profile
+		}
+	}
+	return res, nil
+}
+
+// Profile returns profile by name, empty name selects default profile. Returns empty profile if nothing is
+// configured.
+func (c *Config) Profile(name string) (*Profile, error) {
+	if name == "" {
+		name = c.DefaultProfile
+		if name == "" {
+			return &Profile{}, nil
+		}
+	}
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return nil, errors.New("Profile " + name + " is not found")
+	}
+	if _, e := profile.TimeoutDuration(); e != nil {
+		return nil, errors.New("Invalid timeout in profile " + name + ": " + e.Error())
+	}
+	if profile.Storage.Backend != "" && profile.Storage.Backend != "gcs" {
+		return nil, errors.New("Unsupported storage backend " + profile.Storage.Backend + " in profile " + name)
+	}
+	return profile, nil
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "config")
+	defer os.RemoveAll(dir)
+	global := filepath.Join(dir, "global.yaml")
+	local := filepath.Join(dir, "local.yaml")
+	ioutil.WriteFile(global, []byte(`
+default_profile: prod
+profiles:
+  prod:
+    server: https://api.example.com/api
+    token_file: /tmp/token
+    timeout: 30s
+    batch: 100
+    storage:
+      bucket: data.example.com
+  staging:
+    server: https://staging.example.com/api
+`), 0644)
+	ioutil.WriteFile(local, []byte(`
+profiles:
+  staging:
+    server: http://localhost:9000/api
+    headers:
+      X-City: sf
+    mapbox:
+      token: secret
+      user: borg
+`), 0644)
+
+	cfg, e := LoadConfig([]string{global, local, filepath.Join(dir, "missing.yaml")})
+	if e != nil {
+		t.Fatal(e)
+	}
+	prod, e := cfg.Profile("")
+	if e != nil {
+		t.Fatal(e)
+	}
+	timeout, _ := prod.TimeoutDuration()
+	if prod.Server != "https://api.example.com/api" || prod.Batch != 100 || timeout != 30*time.Second || prod.Storage.Bucket != "data.example.com" {
+		t.Errorf("Unexpected default profile %v", prod)
+	}
+	staging, e := cfg.Profile("staging")
+	if e != nil {
+		t.Fatal(e)
+	}
+	if staging.Server != "http://localhost:9000/api" || staging.Headers["X-City"] != "sf" || staging.Mapbox.User != "borg" {
+		t.Errorf("Unexpected staging profile %v", staging)
+	}
+	_, e = cfg.Profile("unknown")
+	if e == nil {
+		t.Error("Expected error for unknown profile")
+	}
+
+	// Unknown fields are rejected
+	ioutil.WriteFile(local, []byte("profiles:\n  prod:\n    sever: typo\n"), 0644)
+	_, e = LoadConfig([]string{local})
+	if e == nil {
+		t.Error("Expected error for unknown field")
+	}
+}
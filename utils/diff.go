@@ -51,17 +51,15 @@ func isKeywordArrayChanged(src []interface{}, dst []interface{}) bool {
 	if len(src) != len(dst) {
 		return true
 	}
+	counts := make(map[interface{}]int)
 	for _, s := range src {
-		found := false
-		for _, d := range dst {
-			if d == s {
-				found = true
-				break
-			}
-		}
-		if !found {
+		counts[s]++
+	}
+	for _, d := range dst {
+		if counts[d] == 0 {
 			return true
 		}
+		counts[d]--
 	}
 	return false
 }
@@ -133,7 +131,50 @@ func isExtrasKeywordChanged(src []interface{}, dst []interface{}) bool {
 	return false
 }
 
+// Retirement tracking fields. They are updated on every merge, are never sent to the API and are
+// ignored while checking for changes.
+const (
+	TrackingFirstSeen     = "$first_seen"
+	TrackingLastSeen      = "$last_seen"
+	TrackingRetiredAt     = "$retired_at"
+	TrackingRetiredReason = "$retired_reason"
+	TrackingMissingCount  = "$missing_count"
+)
+
+// TrackingFields is a list of all retirement tracking fields
+var TrackingFields = []string{TrackingFirstSeen, TrackingLastSeen, TrackingRetiredAt, TrackingRetiredReason, TrackingMissingCount}
+
+func withoutKeys(src map[string]interface{}, keys []string) map[string]interface{} {
+	found := false
+	for _, k := range keys {
+		if _, ok := src[k]; ok {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return src
+	}
+	res := make(map[string]interface{})
+	for k, v := range src {
+		res[k] = v
+	}
+	for _, k := range keys {
+		delete(res, k)
+	}
+	return res
+}
+
 func IsChanged(src map[string]interface{}, dst map[string]interface{}) (bool, error) {
+	return IsChangedWith(src, dst, ExactComparison)
+}
+
+// IsChangedWith checks if record is changed using provided geometry comparison
+func IsChangedWith(src map[string]interface{}, dst map[string]interface{}, comparison GeometryComparison) (bool, error) {
+
+	// Tracking fields are not imported and are ignored
+	src = withoutKeys(src, TrackingFields)
+	dst = withoutKeys(dst, TrackingFields)
 
 	supportedFlags := make(map[string]bool)
 	supportedFlags["id"] = true
@@ -185,7 +226,11 @@ func IsChanged(src map[string]interface{}, dst map[string]interface{}) (bool, er
 	if ok1 && ok2 {
 		coords1 := geom1.([]interface{})
 		coords2 := geom2.([]interface{})
-		if IsGeometryChanged(coords1, coords2) {
+		changed, e := comparison.IsChanged(coords1, coords2)
+		if e != nil {
+			return true, e
+		}
+		if changed {
 			return true, nil
 		}
 	}
@@ -196,7 +241,11 @@ func IsChanged(src map[string]interface{}, dst map[string]interface{}) (bool, er
 	if ok1 && ok2 {
 		coords1 := geom1.([]interface{})
 		coords2 := geom2.([]interface{})
-		if IsGeometryChanged(coords1, coords2) {
+		changed, e := comparison.IsChanged(coords1, coords2)
+		if e != nil {
+			return true, e
+		}
+		if changed {
 			return true, nil
 		}
 	}
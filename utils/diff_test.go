@@ -152,3 +152,12 @@ func TestRandomSamples(t *testing.T) {
 		`{"displayId":["1-1-201"],"extras":{"enums":[{"key":"zoning","value":["R3-2"]}],"strings":[{"key":"address","value":"1 ELLIS ISLAND"},{"key":"owner_name","value":"U S GOVT LAND \u0026 BLDGS"},{"key":"owner_type","value":"EXCLUDED"},{"key":"shape_type","value":"convex"},{"key":"analyzed","value":"false"},{"key":"project_kassita1","value":"false"},{"key":"project_kassita2","value":"false"}],"floats":[{"key":"area","value":187490.82414773502}],"ints":[{"key":"count_rooms","value":0},{"key":"count_units","value":8},{"key":"count_stories","value":0},{"key":"year_built","value":1900},{"key":"land_value","value":14972400}]},"geometry":[[[[-74.040028,40.700851],[-74.040925,40.700574],[-74.0451,40.697548],[-74.042371,40.695367],[-74.037543,40.698866],[-74.040028,40.700851]]]],"id":"1000010201","retired":false}`,
 		`{"displayId":["1-1-201"],"extras":{"enums":[{"key":"zoning","value":["R3-2"]}],"strings":[{"key":"address","value":"1 ELLIS ISLAND"},{"key":"owner_name","value":"U S GOVT LAND \u0026 BLDGS"},{"key":"owner_type","value":"EXCLUDED"},{"key":"shape_type","value":"convex"},{"key":"analyzed","value":"true"},{"key":"project_kassita1","value":"true"},{"key":"project_kassita2","value":"true"}],"floats":[{"key":"area","value":187490.82414773502},{"key":"project_kassita1_angle","value":-1.9576147956218164},{"key":"project_kassita1_lon","value":-74.04132153737835},{"key":"project_kassita1_lat","value":40.69810900453765},{"key":"project_kassita2_angle","value":-1.9576147956218164},{"key":"project_kassita2_lon","value":-74.04132153737835},{"key":"project_kassita2_lat","value":40.69810900453765}],"ints":[{"key":"count_rooms","value":0},{"key":"count_units","value":8},{"key":"count_stories","value":0},{"key":"year_built","value":1900},{"key":"land_value","value":14972400}]},"geometry":[[[[-74.040028,40.700851],[-74.040925,40.700574],[-74.0451,40.697548],[-74.042371,40.695367],[-74.037543,40.698866],[-74.040028,40.700851]]]],"id":"1000010201","retired":false}`)
 }
+
+func TestTrackingFields(t *testing.T) {
+	assertNotChangedJson(t,
+		`{"id":"1","$first_seen":"2018-01-01","$last_seen":"2018-01-01"}`,
+		`{"id":"1","$first_seen":"2018-01-01","$last_seen":"2018-02-01","$missing_count":1}`)
+	assertChangedJson(t,
+		`{"id":"1","$last_seen":"2018-01-01"}`,
+		`{"id":"1","retired":true,"$retired_at":"2018-02-01","$retired_reason":"missing"}`)
+}
@@ -207,3 +207,36 @@ func IterateFeatures(data []byte, strict bool, displayErrors bool, cb func(featu
 	}
 	return nil
 }
+
+// LoadLineStrings reads all line strings from GeoJSON features. Polygon rings are read as closed lines.
+func LoadLineStrings(data []byte) ([][][]float64, error) {
+	res := make([][][]float64, 0)
+	err := IterateFeaturesRaw(data, func(feature []byte) error {
+		v, t, _, err := jsonparser.Get(feature, "geometry")
+		if err != nil || t == jsonparser.Null {
+			return nil
+		}
+		var g geom.T
+		err = enc.Unmarshal(v, &g)
+		if err != nil {
+			return err
+		}
+		switch g := g.(type) {
+		case *geom.LineString:
+			res = append(res, serializeCoordArray1(g.Coords()))
+		case *geom.MultiLineString:
+			res = append(res, serializeCoordArray2(g.Coords())...)
+		case *geom.Polygon:
+			res = append(res, serializeCoordArray2(g.Coords())...)
+		case *geom.MultiPolygon:
+			for _, p := range serializeCoordArray3(g.Coords()) {
+				res = append(res, p...)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ParseVariables parses query variables. Supported formats are key=value for strings, key:type=value where
+// type is one of string, int, float, bool or json, and @file.json to load variables from a JSON object.
+// Value of json type could be loaded from a file with key:json=@file.json.
+func ParseVariables(args []string) (map[string]interface{}, error) {
+	res := make(map[string]interface{})
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "@") {
+			data, e := ioutil.ReadFile(arg[1:])
+			if e != nil {
+				return nil, e
+			}
+			var vars map[string]interface{}
+			e = json.Unmarshal(data, &vars)
+			if e != nil {
+				return nil, errors.New("Variables file " + arg[1:] + " should contain JSON object: " + e.Error())
+			}
+			for k, v := range vars {
+				res[k] = v
+			}
+			continue
+		}
+		key, value, e := ParseVariable(arg)
+		if e != nil {
+			return nil, e
+		}
+		res[key] = value
+	}
+	return res, nil
+}
+
+// ParseVariable parses single key=value or key:type=value variable
+func ParseVariable(arg string) (string, interface{}, error) {
+	args := strings.SplitN(arg, "=", 2)
+	if len(args) < 2 {
+		return "", nil, errors.New("Query variable mailformed: " + arg)
+	}
+	key := args[0]
+	value := args[1]
+	kind := "string"
+	if i := strings.Index(key, ":"); i >= 0 {
+		kind = key[i+1:]
+		key = key[:i]
+	}
+	if key == "" {
+		return "", nil, errors.New("Query variable name is empty: " + arg)
+	}
+	switch kind {
+	case "string":
+		return key, value, nil
+	case "int":
+		v, e := strconv.ParseInt(value, 10, 64)
+		if e != nil {
+			return "", nil, errors.New("Variable " + key + " should be integer: " + value)
+		}
+		return key, v, nil
+	case "float":
+		v, e := strconv.ParseFloat(value, 64)
+		if e != nil {
+			return "", nil, errors.New("Variable " + key + " should be number: " + value)
+		}
+		return key, v, nil
+	case "bool":
+		v, e := strconv.ParseBool(value)
+		if e != nil {
+			return "", nil, errors.New("Variable " + key + " should be boolean: " + value)
+		}
+		return key, v, nil
+	case "json":
+		data := []byte(value)
+		if strings.HasPrefix(value, "@") {
+			var e error
+			data, e = ioutil.ReadFile(value[1:])
+			if e != nil {
+				return "", nil, e
+			}
+		}
+		var v interface{}
+		e := json.Unmarshal(data, &v)
+		if e != nil {
+			return "", nil, errors.New("Variable " + key + " should be JSON: " + e.Error())
+		}
+		return key, v, nil
+	default:
+		return "", nil, errors.New("Unknown type " + kind + " of variable " + key)
+	}
+}
+
+// LoadQueries reads all .graphql files of a directory, file name without extension is a query name
+func LoadQueries(dir string) (map[string]string, error) {
+	files, e := filepath.Glob(filepath.Join(dir, "*.graphql"))
+	if e != nil {
+		return nil, e
+	}
+	res := make(map[string]string)
+	for _, f := range files {
+		data, e := ioutil.ReadFile(f)
+		if e != nil {
+			return nil, e
+		}
+		res[strings.TrimSuffix(filepath.Base(f), ".graphql")] = string(data)
+	}
+	return res, nil
+}
+
+// variableDefinition matches a single definition after its "$", definitions could be separated by
+// commas or only by whitespace
+var variableDefinition = regexp.MustCompile(`^(\w+)\s*:\s*([\w\[\]!\s]+?)\s*(=|,|@|$)`)
+
+// RequiredVariables returns names of variables with non-null types and without default values
+func RequiredVariables(query string) []string {
+	// Variable definitions are in parentheses before selection set
+	header := query
+	if i := strings.Index(header, "{"); i >= 0 {
+		header = header[:i]
+	}
+	start := strings.Index(header, "(")
+	end := strings.LastIndex(header, ")")
+	if start < 0 || end < start {
+		return []string{}
+	}
+	res := make([]string, 0)
+	for _, definition := range strings.Split(header[start+1:end], "$")[1:] {
+		m := variableDefinition.FindStringSubmatch(strings.TrimSpace(definition))
+		if m != nil && strings.HasSuffix(strings.TrimSpace(m[2]), "!") && m[3] != "=" {
+			res = append(res, m[1])
+		}
+	}
+	return res
+}
+
+// MissingVariables returns required variables of a query that are not provided
+func MissingVariables(query string, variables map[string]interface{}, ignored ...string) []string {
+	res := make([]string, 0)
+	for _, name := range RequiredVariables(query) {
+		skip := false
+		for _, i := range ignored {
+			if i == name {
+				skip = true
+			}
+		}
+		if _, ok := variables[name]; !ok && !skip {
+			res = append(res, name)
+		}
+	}
+	return res
+}
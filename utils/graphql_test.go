@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseVariables(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "variables")
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "vars.json")
+	ioutil.WriteFile(file, []byte(`{"state":"CA","limit":10}`), 0644)
+
+	vars, e := ParseVariables([]string{"@" + file, "city=San Francisco", "limit:int=5", "ratio:float=0.5", "force:bool=true", `ids:json=["1","2"]`, "eq=a=b"})
+	if e != nil {
+		t.Fatal(e)
+	}
+	expected := map[string]interface{}{
+		"state": "CA",
+		"city":  "San Francisco",
+		"limit": int64(5),
+		"ratio": 0.5,
+		"force": true,
+		"ids":   []interface{}{"1", "2"},
+		"eq":    "a=b",
+	}
+	if !reflect.DeepEqual(vars, expected) {
+		t.Errorf("Expected %v, got %v", expected, vars)
+	}
+
+	for _, broken := range []string{"novalue", "n:int=abc", "n:date=1", "j:json=[", ":int=1"} {
+		_, e = ParseVariables([]string{broken})
+		if e == nil {
+			t.Errorf("Expected error for %s", broken)
+		}
+	}
+}
+
+func TestRequiredVariables(t *testing.T) {
+	query := `mutation Import($data: [BlockInput!]!, $state: String!, $county: String, $limit: Int! = 10, $ids: [ID!]) { importBlocks(state: $state, blocks: $data) }`
+	required := RequiredVariables(query)
+	if !reflect.DeepEqual(required, []string{"data", "state"}) {
+		t.Errorf("Unexpected required variables %v", required)
+	}
+	missing := MissingVariables(query, map[string]interface{}{"county": "x"}, "data")
+	if !reflect.DeepEqual(missing, []string{"state"}) {
+		t.Errorf("Unexpected missing variables %v", missing)
+	}
+	required = RequiredVariables("query($a: Int! $b: String!\n$c: ID!\n$d: Int = 1 $e: [ID!]! @deprecated) { ok }")
+	if !reflect.DeepEqual(required, []string{"a", "b", "c", "e"}) {
+		t.Errorf("Unexpected required variables %v", required)
+	}
+	required = RequiredVariables(`query($a: Int! $b: String! $c: ID!) { ok }`)
+	if !reflect.DeepEqual(required, []string{"a", "b", "c"}) {
+		t.Errorf("Unexpected required variables %v", required)
+	}
+	if len(RequiredVariables(`{ ok }`)) != 0 {
+		t.Error("Query without definitions has no required variables")
+	}
+}
+
+func TestLoadQueries(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "queries")
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "lots.graphql"), []byte("query { lots }"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "readme.md"), []byte("ignored"), 0644)
+	queries, e := LoadQueries(dir)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if len(queries) != 1 || queries["lots"] != "query { lots }" {
+		t.Errorf("Unexpected queries %v", queries)
+	}
+}
@@ -3,17 +3,59 @@ package utils
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"time"
 )
 
+// ErrPayloadTooLarge is returned when server rejects request because of its size
+var ErrPayloadTooLarge = errors.New("Payload too large")
+
 type graphQLResponse struct {
 	Data   *interface{}  `json:"data"`
 	Errors []interface{} `json:"errors"`
 }
 
+// GraphQLClient sends authenticated requests to GraphQL endpoint
+type GraphQLClient struct {
+	Endpoint string
+	Headers  map[string]string
+	Token    TokenSource
+	Client   *http.Client
+}
+
+// NewGraphQLClient creates client with request timeout, zero timeout means no timeout
+func NewGraphQLClient(endpoint string, timeout time.Duration) *GraphQLClient {
+	return &GraphQLClient{Endpoint: endpoint, Headers: make(map[string]string), Client: &http.Client{Timeout: timeout}}
+}
+
 func GraqhQLRequest(endpoint string, body string, args map[string]interface{}) (string, error) {
+	return NewGraphQLClient(endpoint, 0).Request(body, args)
+}
+
+func (client *GraphQLClient) do(marshaled []byte) (*http.Response, error) {
+	r, e := http.NewRequest(http.MethodPost, client.Endpoint, bytes.NewBuffer(marshaled))
+	if e != nil {
+		return nil, e
+	}
+	r.Header.Set("Content-Type", "application/json")
+	for k, v := range client.Headers {
+		r.Header.Set(k, v)
+	}
+	if client.Token != nil {
+		token, e := client.Token.Token()
+		if e != nil {
+			return nil, e
+		}
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return client.Client.Do(r)
+}
+
+// Request performs GraphQL query
+func (client *GraphQLClient) Request(body string, args map[string]interface{}) (string, error) {
 
 	//
 	// Prepare Query
@@ -25,22 +67,28 @@ func GraqhQLRequest(endpoint string, body string, args map[string]interface{}) (
 	if e != nil {
 		return "", e
 	}
-	r, e := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(marshaled))
-	if e != nil {
-		return "", e
-	}
-	r.Header.Set("Content-Type", "application/json")
 
 	//
 	// Executing
 	//
 
-	client := http.Client{}
-	response, e := client.Do(r)
+	response, e := client.do(marshaled)
 	if e != nil {
 		return "", e
 	}
+	if response.StatusCode == http.StatusUnauthorized && client.Token != nil {
+		// Token could be expired or revoked, try once more with a fresh one
+		response.Body.Close()
+		client.Token.Invalidate()
+		response, e = client.do(marshaled)
+		if e != nil {
+			return "", e
+		}
+	}
 	defer response.Body.Close()
+	if response.StatusCode == http.StatusRequestEntityTooLarge {
+		return "", ErrPayloadTooLarge
+	}
 	responseBody, e := ioutil.ReadAll(response.Body)
 	if e != nil {
 		return "", e
@@ -52,7 +100,11 @@ func GraqhQLRequest(endpoint string, body string, args map[string]interface{}) (
 
 	var responseText graphQLResponse
 	e = json.Unmarshal(responseBody, &responseText)
+	failed := response.StatusCode < 200 || response.StatusCode >= 300
 	if e != nil {
+		if failed {
+			return "", fmt.Errorf("Server responded with %s", response.Status)
+		}
 		return "", e
 	}
 
@@ -60,8 +112,14 @@ func GraqhQLRequest(endpoint string, body string, args map[string]interface{}) (
 	// Handle Errors
 	//
 	if len(responseText.Errors) > 0 {
+		if failed {
+			return "", fmt.Errorf("Server responded with %s, errors: %v", response.Status, responseText.Errors)
+		}
 		return "", fmt.Errorf("Errors: %v", responseText.Errors)
 	}
+	if failed {
+		return "", fmt.Errorf("Server responded with %s", response.Status)
+	}
 
 	result, _ := json.Marshal(responseText.Data)
 	return string(result), nil
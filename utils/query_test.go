@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRequestStatus(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/unavailable":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `{"data":{"ok":true}}`)
+		case "/large":
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+		case "/errors":
+			fmt.Fprint(w, `{"errors":[{"message":"Value is too large"}]}`)
+		default:
+			fmt.Fprint(w, `{"data":{"ok":true}}`)
+		}
+	}))
+	defer api.Close()
+
+	res, e := NewGraphQLClient(api.URL, time.Second).Request("query { ok }", map[string]interface{}{})
+	if e != nil || res != `{"ok":true}` {
+		t.Errorf("Unexpected response %s, %v", res, e)
+	}
+
+	// Any non successful status is an error even with valid response
+	_, e = NewGraphQLClient(api.URL+"/unavailable", time.Second).Request("query { ok }", map[string]interface{}{})
+	if e == nil {
+		t.Error("Expected error for unavailable server")
+	}
+
+	// Only status code means that payload is too large
+	_, e = NewGraphQLClient(api.URL+"/large", time.Second).Request("query { ok }", map[string]interface{}{})
+	if e != ErrPayloadTooLarge {
+		t.Errorf("Expected payload too large, got %v", e)
+	}
+	_, e = NewGraphQLClient(api.URL+"/errors", time.Second).Request("query { ok }", map[string]interface{}{})
+	if e == nil || e == ErrPayloadTooLarge {
+		t.Errorf("Expected GraphQL error, got %v", e)
+	}
+}